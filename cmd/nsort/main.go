@@ -0,0 +1,314 @@
+// Command nsort sorts lines of text like sort(1), but orders them with
+// the normalizers and comparators of package normalizedsort, so that shell
+// pipelines and Go programs agree on ordering.
+//
+// Usage:
+//
+//	nsort [flags] [file ...]
+//
+// With no files, or when file is -, nsort reads standard input. Lines are
+// compared by their normalized key and then by their raw bytes, as in
+// normalizedsort.New. Without an ordering flag, lines are folded with
+// strings.ToLower, like normalizedsort.Sort with a nil normalizer; as with
+// Sort, other normalizers replace that default rather than add to it, so
+// combine --natural with --fold-case to fold case as well. To compare raw
+// bytes alone, like sort(1) in the C locale, use --bytes.
+//
+// Exit status is 0 on success, 1 if --check finds disorder, and 2 on error.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+type config struct {
+	foldCase, natural, version bool
+	bytes                      bool
+	scheme, collate            string
+	separator, key             string
+	unique, reverse, stable    bool
+	check                      bool
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var c config
+	fs := flag.NewFlagSet("nsort", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	boolVar := func(p *bool, short, long, usage string) {
+		fs.BoolVar(p, short, false, usage)
+		fs.BoolVar(p, long, false, "alias for -"+short)
+	}
+	stringVar := func(p *string, short, long, value, usage string) {
+		fs.StringVar(p, short, value, usage)
+		fs.StringVar(p, long, value, "alias for -"+short)
+	}
+	boolVar(&c.foldCase, "f", "fold-case", "compare case-insensitively (the default without other ordering flags)")
+	boolVar(&c.bytes, "b", "bytes", "compare raw bytes, without normalization")
+	boolVar(&c.natural, "n", "natural", "compare runs of digits by numeric value")
+	boolVar(&c.version, "V", "version", "compare as version numbers (see --version-scheme)")
+	fs.StringVar(&c.scheme, "version-scheme", "debian", "version `scheme` for --version: debian, rpm, or semver")
+	fs.StringVar(&c.collate, "collate", "", "compare by the alphabet of `locale`, one of "+
+		strings.Join(normalizedsort.Locales(), ", "))
+	stringVar(&c.separator, "t", "field-separator", "", "use `sep` instead of runs of blanks to split fields")
+	stringVar(&c.key, "k", "key", "", "compare by fields `N[,M]` (1-based, inclusive) instead of the whole line")
+	boolVar(&c.unique, "u", "unique", "output only the first of lines with equal keys")
+	boolVar(&c.reverse, "r", "reverse", "reverse the result of comparisons")
+	boolVar(&c.stable, "s", "stable", "keep lines with equal keys in input order")
+	boolVar(&c.check, "c", "check", "check whether input is sorted; do not sort")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: nsort [flags] [file ...]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	s, err := newSorter(c)
+	if err != nil {
+		fmt.Fprintf(stderr, "nsort: %v\n", err)
+		return 2
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var lines []string
+	for _, name := range files {
+		if lines, err = readLines(lines, name, stdin); err != nil {
+			fmt.Fprintf(stderr, "nsort: %v\n", err)
+			return 2
+		}
+	}
+
+	if c.check {
+		if i := s.disorder(lines); i >= 0 {
+			fmt.Fprintf(stderr, "nsort: disorder at line %d: %s\n", i+1, lines[i])
+			return 1
+		}
+		return 0
+	}
+
+	bw := bufio.NewWriter(stdout)
+	for _, line := range s.sort(lines) {
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+	if err = bw.Flush(); err != nil {
+		fmt.Fprintf(stderr, "nsort: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+func readLines(lines []string, name string, stdin io.Reader) ([]string, error) {
+	r := stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sorter holds the ordering selected by the command line flags.
+type sorter struct {
+	config
+	field     func(string) string
+	normalize func(string) string
+	compare   func(a, b string) int
+	// first and last are the 0-based field range of --key; last is -1
+	// for the end of the line.
+	first, last int
+}
+
+func newSorter(c config) (*sorter, error) {
+	s := sorter{config: c, first: -1}
+	if c.key != "" {
+		if err := s.parseKey(c.key); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.collate != "" && c.foldCase {
+		return nil, fmt.Errorf("--collate cannot be combined with --fold-case")
+	}
+	var normalizers []func(string) string
+	if c.collate != "" {
+		coll, err := normalizedsort.NewCollation(c.collate)
+		if err != nil {
+			return nil, err
+		}
+		normalizers = append(normalizers, coll.Normalize)
+	} else if c.foldCase {
+		normalizers = append(normalizers, strings.ToLower)
+	}
+	if c.natural {
+		normalizers = append(normalizers, normalizedsort.Natural)
+	}
+
+	switch {
+	case c.bytes && (c.version || len(normalizers) > 0):
+		return nil, fmt.Errorf("--bytes cannot be combined with other ordering flags")
+	case c.bytes:
+		return &s, nil
+	case c.version:
+		if len(normalizers) > 0 {
+			return nil, fmt.Errorf("--version cannot be combined with other ordering flags")
+		}
+		switch c.scheme {
+		case "debian":
+			s.compare = normalizedsort.CompareDebian
+		case "rpm":
+			s.compare = normalizedsort.CompareRPM
+		case "semver":
+			s.compare = normalizedsort.CompareSemVer
+		default:
+			return nil, fmt.Errorf("unknown version scheme %q", c.scheme)
+		}
+		return &s, nil
+	case len(normalizers) == 0:
+		s.normalize = strings.ToLower
+		return &s, nil
+	}
+	s.normalize = func(v string) string {
+		for _, f := range normalizers {
+			v = f(v)
+		}
+		return v
+	}
+	return &s, nil
+}
+
+func (s *sorter) parseKey(key string) error {
+	first, last, hasLast := strings.Cut(key, ",")
+	n, err := strconv.Atoi(first)
+	if err != nil || n < 1 {
+		return fmt.Errorf("invalid field number in --key %q", key)
+	}
+	s.first, s.last = n-1, -1
+	if hasLast {
+		m, err := strconv.Atoi(last)
+		if err != nil || m < n {
+			return fmt.Errorf("invalid field range in --key %q", key)
+		}
+		s.last = m - 1
+	}
+	return nil
+}
+
+// keyOf returns the part of line selected by --key.
+func (s *sorter) keyOf(line string) string {
+	if s.first < 0 {
+		return line
+	}
+	var fields []string
+	sep := s.separator
+	if sep == "" {
+		fields, sep = strings.Fields(line), " "
+	} else {
+		fields = strings.Split(line, sep)
+	}
+	if s.first >= len(fields) {
+		return ""
+	}
+	fields = fields[s.first:]
+	if s.last >= 0 && s.last-s.first+1 < len(fields) {
+		fields = fields[:s.last-s.first+1]
+	}
+	return strings.Join(fields, sep)
+}
+
+type entry struct {
+	line, key string
+}
+
+// cmp compares the keys of a and b, ignoring the tie-break.
+func (s *sorter) cmp(a, b *entry) int {
+	c := 0
+	if s.compare != nil {
+		c = s.compare(a.key, b.key)
+	} else {
+		c = strings.Compare(a.key, b.key)
+	}
+	if s.reverse {
+		c = -c
+	}
+	return c
+}
+
+// less orders a before b, breaking ties between equal keys by raw line
+// unless --stable is set.
+func (s *sorter) less(a, b *entry) bool {
+	if c := s.cmp(a, b); c != 0 || s.stable {
+		return c < 0
+	}
+	if s.reverse {
+		return a.line > b.line
+	}
+	return a.line < b.line
+}
+
+func (s *sorter) entries(lines []string) []entry {
+	es := make([]entry, len(lines))
+	for i, line := range lines {
+		es[i].line = line
+		es[i].key = s.keyOf(line)
+		if s.normalize != nil {
+			es[i].key = s.normalize(es[i].key)
+		}
+	}
+	return es
+}
+
+func (s *sorter) sort(lines []string) []string {
+	es := s.entries(lines)
+	sort.SliceStable(es, func(i, j int) bool { return s.less(&es[i], &es[j]) })
+	out := lines[:0]
+	for i := range es {
+		if s.unique && i > 0 && s.cmp(&es[i-1], &es[i]) == 0 {
+			continue
+		}
+		out = append(out, es[i].line)
+	}
+	return out
+}
+
+// disorder returns the index of the first line out of order, or -1.
+func (s *sorter) disorder(lines []string) int {
+	es := s.entries(lines)
+	for i := 1; i < len(es); i++ {
+		if s.less(&es[i], &es[i-1]) || s.unique && s.cmp(&es[i-1], &es[i]) == 0 {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		args     []string
+		in, want string
+		code     int
+	}{
+		{nil, "b\nB\na\n", "a\nB\nb\n", 0},
+		{[]string{"--bytes"}, "b\nB\na\n", "B\na\nb\n", 0},
+		{[]string{"--fold-case"}, "b\nB\na\n", "a\nB\nb\n", 0},
+		{[]string{"-f", "-r"}, "b\nB\na\n", "b\nB\na\n", 0},
+		{[]string{"--natural"}, "x10\nx9\nx09\n", "x09\nx9\nx10\n", 0},
+		{[]string{"--version"}, "1.10.0\n1.9.0\n1.9.0~rc1\n", "1.9.0~rc1\n1.9.0\n1.10.0\n", 0},
+		{[]string{"--version", "--version-scheme=semver"}, "1.0.0\n1.0.0-rc.1\n", "1.0.0-rc.1\n1.0.0\n", 0},
+		{[]string{"--collate=sv"}, "Örebro\nZürich\nÅre\n", "Zürich\nÅre\nÖrebro\n", 0},
+		{[]string{"-t", ",", "-k", "2"}, "x,b\ny,a\n", "y,a\nx,b\n", 0},
+		{[]string{"-k", "2,2", "-f", "-u"}, "1 B z\n2 a y\n3 b x\n", "2 a y\n1 B z\n", 0},
+		{[]string{"-k", "2", "-s"}, "3 a\n1 a\n2 a\n", "3 a\n1 a\n2 a\n", 0},
+		{[]string{"-k", "2"}, "3 a\n1 a\n2 a\n", "1 a\n2 a\n3 a\n", 0},
+		{[]string{"--check", "-f"}, "a\nB\nc\n", "", 0},
+		{[]string{"--check"}, "a\nB\nc\n", "", 0},
+		{[]string{"--check", "-b"}, "a\nB\nc\n", "", 1},
+		{[]string{"--check", "-u"}, "a\na\n", "", 1},
+		{[]string{"--collate=xx"}, "", "", 2},
+		{[]string{"--collate=sv", "-f"}, "", "", 2},
+		{[]string{"--bytes", "-n"}, "", "", 2},
+		{[]string{"-k", "0"}, "", "", 2},
+	} {
+		var stdout, stderr bytes.Buffer
+		code := run(tc.args, strings.NewReader(tc.in), &stdout, &stderr)
+		if code != tc.code || stdout.String() != tc.want {
+			t.Errorf("nsort %q: got %d %q; want %d %q (stderr %q)",
+				tc.args, code, stdout.String(), tc.code, tc.want, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package normalizedsort
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// foldTable maps lower-case Latin letters with diacritics, ligatures, and
+// other letters outside ASCII to their closest ASCII spelling.
+var foldTable = func() map[rune]string {
+	m := map[rune]string{
+		'ß': "ss", 'æ': "ae", 'œ': "oe", 'þ': "th", 'ĳ': "ij",
+		'ŋ': "ng", 'ſ': "s", 'ð': "d", 'đ': "d", 'ħ': "h",
+		'ı': "i", 'ł': "l", 'ø': "o", 'ŧ': "t",
+	}
+	for base, letters := range map[string]string{
+		"a": "àáâãäåāăąǎǻȁȃạảấầẩẫậắằẳẵặ",
+		"c": "çćĉċč",
+		"d": "ď",
+		"e": "èéêëēĕėęěȅȇẹẻẽếềểễệ",
+		"g": "ĝğġģǧ",
+		"h": "ĥ",
+		"i": "ìíîïĩīĭįǐȉȋỉị",
+		"j": "ĵǰ",
+		"k": "ķǩ",
+		"l": "ĺļľŀ",
+		"n": "ñńņňŉǹ",
+		"o": "òóôõöōŏőơǒǿȍȏọỏốồổỗộớờởỡợ",
+		"r": "ŕŗřȑȓ",
+		"s": "śŝşšș",
+		"t": "ţťț",
+		"u": "ùúûüũūŭůűųưǔǖǘǚǜȕȗụủứừửữự",
+		"w": "ŵẁẃẅ",
+		"y": "ýÿŷỳỵỷỹ",
+		"z": "źżž",
+	} {
+		for _, r := range letters {
+			m[r] = base
+		}
+	}
+	return m
+}()
+
+// FoldDiacritics replaces Latin letters with diacritics and ligatures
+// with their closest ASCII spelling, preserving case: "Ærøskøbing"
+// becomes "Aeroskobing". Other characters are unchanged.
+func FoldDiacritics(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		writeFolded(&b, r)
+	}
+	return b.String()
+}
+
+func writeFolded(b *strings.Builder, r rune) {
+	if r < 0x80 {
+		b.WriteByte(byte(r))
+		return
+	}
+	lower := unicode.ToLower(r)
+	f, ok := foldTable[lower]
+	if !ok {
+		b.WriteRune(r)
+		return
+	}
+	if lower != r {
+		// Title case, so that "Æ" becomes "Ae", not "AE".
+		f = strings.ToUpper(f[:1]) + f[1:]
+	}
+	b.WriteString(f)
+}
+
+// A Collation orders strings according to the alphabet of a locale.
+// Letters are compared case-insensitively and with their diacritics
+// removed, except for the letters the locale treats as distinct, which
+// sort after their base letter: in Swedish "å", "ä", and "ö" follow "z",
+// and in Spanish "ñ" follows "n".
+type Collation struct {
+	// Locale is the language code of the Collation, such as "sv".
+	Locale string
+	// tailored maps each distinct letter to its position after its base,
+	// and letters is its inverse.
+	tailored map[rune]tailoring
+	letters  map[tailoring]rune
+	// alphabet lists the primary letters in order.
+	alphabet []string
+	lower    func(rune) rune
+}
+
+type tailoring struct {
+	base  byte
+	index byte
+}
+
+// tailorMark separates a tailored letter's base from its index in keys.
+// It is not valid UTF-8, so it sorts after anything that may follow the
+// base letter in an untailored key.
+const tailorMark = 0xff
+
+// tailorings lists the distinct letters of each locale as strings of an
+// ASCII base letter followed by the letters that come after it, in order.
+var tailorings = map[string][]string{
+	"cs": {"cč", "rř", "sš", "zž"},
+	"da": {"zæøå"},
+	"de": nil,
+	"en": nil,
+	"es": {"nñ"},
+	"fi": {"zåäö"},
+	"fr": nil,
+	"is": {"aá", "dð", "eé", "ií", "oó", "uú", "yý", "zþæö"},
+	"it": nil,
+	"nl": nil,
+	"no": {"zæøå"},
+	"pl": {"aą", "cć", "eę", "lł", "nń", "oó", "sś", "zźż"},
+	"pt": nil,
+	"sv": {"zåäö"},
+	"tr": {"cç", "gğ", "hı", "oö", "sş", "uü"},
+}
+
+// Locales returns the locales with a Collation, in sorted order.
+func Locales() []string {
+	locales := make([]string, 0, len(tailorings))
+	for locale := range tailorings {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// NewCollation returns the Collation for locale, a language code such as
+// "sv" or "es". A region suffix, as in "sv-SE" or "pt_BR", is ignored.
+func NewCollation(locale string) (*Collation, error) {
+	lang := strings.ToLower(locale)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	tails, ok := tailorings[lang]
+	if !ok {
+		return nil, fmt.Errorf("normalizedsort: no collation for locale %q", locale)
+	}
+	c := Collation{
+		Locale:   lang,
+		tailored: make(map[rune]tailoring),
+		letters:  make(map[tailoring]rune),
+		lower:    unicode.ToLower,
+	}
+	if lang == "tr" {
+		c.lower = unicode.TurkishCase.ToLower
+	}
+	after := make(map[byte][]rune)
+	for _, t := range tails {
+		rs := []rune(t)
+		base := byte(rs[0])
+		for i, r := range rs[1:] {
+			t := tailoring{base, byte(i + 1)}
+			c.tailored[r] = t
+			c.letters[t] = r
+		}
+		after[base] = rs[1:]
+	}
+	for base := byte('a'); base <= 'z'; base++ {
+		c.alphabet = append(c.alphabet, string(base))
+		for _, r := range after[base] {
+			c.alphabet = append(c.alphabet, string(r))
+		}
+	}
+	return &c, nil
+}
+
+// Normalize returns the sort key of s in the Collation. It is suitable as
+// the normalize argument of Sort and New. The key is not valid UTF-8 if s
+// contains any of the locale's distinct letters.
+func (c *Collation) Normalize(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		r = c.lower(r)
+		if t, ok := c.tailored[r]; ok {
+			b.WriteByte(t.base)
+			b.WriteByte(tailorMark)
+			b.WriteByte(t.index)
+			continue
+		}
+		writeFolded(&b, r)
+	}
+	return b.String()
+}
+
+// Alphabet returns the letters of the Collation in order, including the
+// locale's distinct letters.
+func (c *Collation) Alphabet() []string {
+	return append([]string(nil), c.alphabet...)
+}
@@ -0,0 +1,78 @@
+package normalizedsort_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func ExampleNatural() {
+	slice := []string{"file10.txt", "file2.txt", "file1.txt", "file02.txt", "File3.txt"}
+	normalizedsort.Sort(slice, normalizedsort.Natural)
+	fmt.Printf("%q\n", slice)
+	// Output: ["File3.txt" "file1.txt" "file02.txt" "file2.txt" "file10.txt"]
+}
+
+func ExampleFoldDiacritics() {
+	fmt.Println(normalizedsort.FoldDiacritics("Ærøskøbing, Łódź, Straße"))
+	// Output: Aeroskobing, Lodz, Strasse
+}
+
+func ExampleCollation() {
+	slice := []string{"Örebro", "Zürich", "Ystad", "Åre", "Arvika", "Älmhult"}
+	sv, err := normalizedsort.NewCollation("sv-SE")
+	if err != nil {
+		panic(err)
+	}
+	normalizedsort.Sort(slice, sv.Normalize)
+	fmt.Printf("%q\n", slice)
+
+	de, _ := normalizedsort.NewCollation("de")
+	normalizedsort.Sort(slice, de.Normalize)
+	fmt.Printf("%q\n", slice)
+	// Output:
+	// ["Arvika" "Ystad" "Zürich" "Åre" "Älmhult" "Örebro"]
+	// ["Älmhult" "Åre" "Arvika" "Örebro" "Ystad" "Zürich"]
+}
+
+func TestNatural(t *testing.T) {
+	ordered := []string{
+		"", "0", "1", "2", "9", "10", "99", "123456789", "1234567890",
+		"99999999999999999999", "100000000000000000000", "a", "a1", "a1b",
+		"a2", "a10", "a10b2", "a10b10", "b",
+	}
+	for i := 0; i < len(ordered)-1; i++ {
+		a, b := normalizedsort.Natural(ordered[i]), normalizedsort.Natural(ordered[i+1])
+		if a >= b {
+			t.Errorf("Natural(%q) = %q >= Natural(%q) = %q", ordered[i], a, ordered[i+1], b)
+		}
+	}
+}
+
+func TestCollation(t *testing.T) {
+	for _, tc := range []struct {
+		locale  string
+		ordered []string
+	}{
+		{"es", []string{"nube", "Núñez", "ñandú", "oso"}},
+		{"tr", []string{"çay", "dag", "ığdır", "Isparta", "iğne", "İzmir", "jale"}},
+		{"pl", []string{"lody", "łódź", "mama", "zebra", "źle", "żaba"}},
+		{"da", []string{"zoo", "Ærø", "Øster", "Århus"}},
+		{"en", []string{"Ærø", "Århus", "Øster", "zoo"}},
+	} {
+		c, err := normalizedsort.NewCollation(tc.locale)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < len(tc.ordered)-1; i++ {
+			a, b := c.Normalize(tc.ordered[i]), c.Normalize(tc.ordered[i+1])
+			if a >= b {
+				t.Errorf("%s: %q >= %q", tc.locale, tc.ordered[i], tc.ordered[i+1])
+			}
+		}
+	}
+	if _, err := normalizedsort.NewCollation("xx"); err == nil {
+		t.Error("expected error for unknown locale")
+	}
+}
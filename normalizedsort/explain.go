@@ -0,0 +1,157 @@
+package normalizedsort
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Rule names the step of the ordering of New that decided how two
+// strings compare.
+type Rule int
+
+const (
+	// Identical means the strings are equal, so neither sorts first.
+	Identical Rule = iota
+	// PrimaryKey means the normalized forms differ.
+	PrimaryKey
+	// Tailoring means the normalized forms differ at a letter that a
+	// Collation's locale treats as distinct from its base letter, such as
+	// "ö" in Swedish, so that the order differs from the plain alphabet.
+	Tailoring
+	// TieBreak means the normalized forms are equal and the strings were
+	// ordered by their raw form.
+	TieBreak
+)
+
+func (r Rule) String() string {
+	switch r {
+	case Identical:
+		return "identical"
+	case PrimaryKey:
+		return "primary key"
+	case Tailoring:
+		return "locale tailoring"
+	case TieBreak:
+		return "tie-break"
+	}
+	return fmt.Sprintf("Rule(%d)", int(r))
+}
+
+// An Explanation reports why two strings sort in the order they do.
+type Explanation struct {
+	A, B                     string
+	NormalizedA, NormalizedB string
+	// Order is -1 if A sorts before B, +1 if it sorts after, and 0 if
+	// they are identical.
+	Order int
+	// Rule is the step that decided Order.
+	Rule Rule
+	// Pos is the byte offset of the first difference, in the normalized
+	// forms for PrimaryKey and Tailoring and in the raw forms for
+	// TieBreak. It is -1 for Identical.
+	Pos int
+}
+
+// String describes e in a sentence, such as
+// `"apple" sorts before "Banana": primary key "apple" < "banana" at byte 0`.
+func (e Explanation) String() string {
+	if e.Rule == Identical {
+		return fmt.Sprintf("%q and %q are identical", e.A, e.B)
+	}
+	order, op := "before", "<"
+	if e.Order > 0 {
+		order, op = "after", ">"
+	}
+	x, y := e.NormalizedA, e.NormalizedB
+	if e.Rule == TieBreak {
+		x, y = e.A, e.B
+	}
+	return fmt.Sprintf("%q sorts %s %q: %v %q %s %q at byte %d",
+		e.A, order, e.B, e.Rule, x, op, y, e.Pos)
+}
+
+// Explain reports how a and b compare in a sort.Interface returned by
+// New(ss, normalize). If normalize is nil, strings.ToLower is used.
+// Tailoring is only reported for the normalization of a Collation.
+func Explain(a, b string, normalize func(string) string) Explanation {
+	if normalize == nil {
+		normalize = strings.ToLower
+	}
+	e := Explanation{
+		A: a, B: b,
+		NormalizedA: normalize(a), NormalizedB: normalize(b),
+		Pos: -1,
+	}
+	x, y := e.NormalizedA, e.NormalizedB
+	switch {
+	case x != y:
+		e.Rule = PrimaryKey
+	case a != b:
+		e.Rule = TieBreak
+		x, y = a, b
+	default:
+		return e
+	}
+	e.Pos = mismatch(x, y)
+	e.Order = strings.Compare(x, y)
+	if e.Rule == PrimaryKey && (isTailored(x, e.Pos) || isTailored(y, e.Pos)) {
+		e.Rule = Tailoring
+	}
+	return e
+}
+
+// mismatch returns the index of the first byte at which a and b differ,
+// or the length of the shorter if one is a prefix of the other.
+func mismatch(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// isTailored reports whether byte i of the Collation key k is the mark or
+// index of a tailored letter.
+func isTailored(k string, i int) bool {
+	return i < len(k) && k[i] == tailorMark ||
+		i > 0 && i <= len(k) && k[i-1] == tailorMark
+}
+
+// IsSorted reports whether ss is sorted as by Sort(ss, normalize).
+func IsSorted(ss []string, normalize func(string) string) bool {
+	return len(violations(ss, normalize, 1)) == 0
+}
+
+// A Violation is a pair of adjacent strings out of order.
+type Violation struct {
+	// Index is the position of B in the slice; A is at Index-1.
+	Index int
+	Explanation
+}
+
+// FindViolations returns the adjacent pairs of ss that are out of order
+// for Sort(ss, normalize), with an explanation of each.
+func FindViolations(ss []string, normalize func(string) string) []Violation {
+	return violations(ss, normalize, -1)
+}
+
+// violations finds up to max violations in ss, or all if max < 0,
+// normalizing each string once.
+func violations(ss []string, normalize func(string) string, max int) []Violation {
+	if normalize == nil {
+		normalize = strings.ToLower
+	}
+	var vs []Violation
+	var prev string
+	for i, s := range ss {
+		k := normalize(s)
+		if i > 0 && (k < prev || k == prev && s < ss[i-1]) {
+			vs = append(vs, Violation{i, Explain(ss[i-1], s, normalize)})
+			if len(vs) == max {
+				break
+			}
+		}
+		prev = k
+	}
+	return vs
+}
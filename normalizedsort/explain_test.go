@@ -0,0 +1,80 @@
+package normalizedsort_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func ExampleExplain() {
+	fmt.Println(normalizedsort.Explain("apple", "Banana", nil))
+	fmt.Println(normalizedsort.Explain("apple", "Apple", nil))
+	sv, _ := normalizedsort.NewCollation("sv")
+	e := normalizedsort.Explain("Öberg", "Zetterberg", sv.Normalize)
+	fmt.Println(e.Order, e.Rule, e.Pos)
+	// Output:
+	// "apple" sorts before "Banana": primary key "apple" < "banana" at byte 0
+	// "apple" sorts after "Apple": tie-break "apple" > "Apple" at byte 0
+	// 1 locale tailoring 1
+}
+
+func ExampleFindViolations() {
+	ss := []string{"alpha", "Gamma", "beta", "delta", "Delta"}
+	for _, v := range normalizedsort.FindViolations(ss, nil) {
+		fmt.Println(v.Index, v.Rule)
+	}
+	// Output:
+	// 2 primary key
+	// 4 tie-break
+}
+
+func TestExplain(t *testing.T) {
+	es, _ := normalizedsort.NewCollation("es")
+	sv, _ := normalizedsort.NewCollation("sv")
+	for _, tc := range []struct {
+		a, b      string
+		normalize func(string) string
+		order     int
+		rule      normalizedsort.Rule
+		pos       int
+	}{
+		{"same", "same", nil, 0, normalizedsort.Identical, -1},
+		{"abc", "ABD", nil, -1, normalizedsort.PrimaryKey, 2},
+		{"ab", "abc", nil, -1, normalizedsort.PrimaryKey, 2},
+		{"ABC", "abc", nil, -1, normalizedsort.TieBreak, 0},
+		{"nz", "ñ", es.Normalize, -1, normalizedsort.Tailoring, 1},
+		{"ño", "ñu", es.Normalize, -1, normalizedsort.PrimaryKey, 3},
+		{"ñ", "o", es.Normalize, -1, normalizedsort.PrimaryKey, 0},
+		{"å", "ä", sv.Normalize, -1, normalizedsort.Tailoring, 2},
+		{"Ö", "ö", sv.Normalize, -1, normalizedsort.TieBreak, 1},
+	} {
+		e := normalizedsort.Explain(tc.a, tc.b, tc.normalize)
+		if e.Order != tc.order || e.Rule != tc.rule || e.Pos != tc.pos {
+			t.Errorf("Explain(%q, %q) = %d %v %d; want %d %v %d",
+				tc.a, tc.b, e.Order, e.Rule, e.Pos, tc.order, tc.rule, tc.pos)
+		}
+		if r := normalizedsort.Explain(tc.b, tc.a, tc.normalize); r.Order != -e.Order || r.Rule != e.Rule {
+			t.Errorf("Explain(%q, %q) is not symmetric: %v", tc.b, tc.a, r)
+		}
+	}
+}
+
+func TestIsSorted(t *testing.T) {
+	lines := randomLines(1000)
+	if normalizedsort.IsSorted(lines, nil) {
+		t.Fatal("random lines reported sorted")
+	}
+	normalizedsort.Sort(lines, nil)
+	if !normalizedsort.IsSorted(lines, nil) {
+		t.Error("sorted lines reported unsorted")
+	}
+	if vs := normalizedsort.FindViolations(lines, nil); len(vs) != 0 {
+		t.Errorf("FindViolations(sorted) = %v", vs)
+	}
+	lines[10], lines[500] = lines[500], lines[10]
+	vs := normalizedsort.FindViolations(lines, nil)
+	if len(vs) == 0 || vs[0].Index != 11 && vs[0].Index != 10 {
+		t.Errorf("FindViolations after swap = %v", vs)
+	}
+}
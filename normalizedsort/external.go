@@ -0,0 +1,341 @@
+package normalizedsort
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefaultMemoryLimit is the memory budget used by an ExternalSorter with
+// a zero MemoryLimit.
+const DefaultMemoryLimit = 64 << 20
+
+// mergeFanIn is the maximum number of runs merged at once. Sorts with more
+// runs than this merge in several passes to bound open files. Runs are
+// otherwise kept closed.
+const mergeFanIn = 64
+
+// itemOverhead approximates the memory used by an item beyond its strings.
+const itemOverhead = 64
+
+// An ExternalSorter sorts lines of text that may not fit in memory.
+// It reads lines into bounded chunks, sorts each chunk, spills it to a
+// temporary file, and merges the sorted files. Inputs that fit within
+// the memory budget are sorted without touching the disk. The zero value
+// sorts like Sort with a nil normalization function.
+type ExternalSorter struct {
+	// Normalize is the normalization function, as for New. If both
+	// Normalize and Compare are nil, strings.ToLower is used.
+	Normalize func(string) string
+	// Compare, if not nil, orders lines instead of Normalize, as for NewFunc.
+	Compare func(a, b string) int
+	// MemoryLimit is the approximate number of bytes of lines and keys
+	// held in memory at once. If zero, DefaultMemoryLimit is used.
+	MemoryLimit int
+	// TempDir is the directory for temporary files. If empty, the default
+	// directory for temporary files is used.
+	TempDir string
+}
+
+// Sort reads newline separated lines from r and writes them in sorted
+// order to w, each followed by a newline.
+func (es *ExternalSorter) Sort(w io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+
+	normalize, less := es.Normalize, keyLess
+	if es.Compare != nil {
+		normalize, less = nil, funcLess(es.Compare)
+	} else if normalize == nil {
+		normalize = strings.ToLower
+	}
+
+	next := func() (item, error) {
+		line, err := br.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return item{}, io.EOF
+		}
+		if err != nil && err != io.EOF {
+			return item{}, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		it := item{raw: line}
+		if normalize != nil {
+			it.key = normalize(line)
+		}
+		return it, nil
+	}
+	emit := func(raw string) error {
+		if _, err := bw.WriteString(raw); err != nil {
+			return err
+		}
+		return bw.WriteByte('\n')
+	}
+
+	rs := runSorter{less: less, limit: es.MemoryLimit, dir: es.TempDir}
+	if err := rs.sort(next, emit); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// An item is a unit of external sorting: its raw form and a precomputed key.
+type item struct {
+	key, raw string
+}
+
+func keyLess(a, b *item) bool {
+	if a.key == b.key {
+		return a.raw < b.raw
+	}
+	return a.key < b.key
+}
+
+func funcLess(cmp func(a, b string) int) func(a, b *item) bool {
+	return func(a, b *item) bool {
+		if c := cmp(a.raw, b.raw); c != 0 {
+			return c < 0
+		}
+		return a.raw < b.raw
+	}
+}
+
+// runSorter implements external merge sorting of items.
+type runSorter struct {
+	less  func(a, b *item) bool
+	limit int
+	dir   string
+	// runs holds the names of the sorted run files.
+	runs []string
+}
+
+// sort reads items from next until io.EOF and passes their raw forms
+// to emit in sorted order.
+func (rs *runSorter) sort(next func() (item, error), emit func(raw string) error) error {
+	defer func() {
+		for _, name := range rs.runs {
+			os.Remove(name)
+		}
+	}()
+
+	limit := rs.limit
+	if limit <= 0 {
+		limit = DefaultMemoryLimit
+	}
+	var (
+		chunk []item
+		size  int
+		err   error
+	)
+	for {
+		var it item
+		it, err = next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		chunk = append(chunk, it)
+		size += len(it.key) + len(it.raw) + itemOverhead
+		if size >= limit {
+			if err = rs.spill(chunk); err != nil {
+				return err
+			}
+			chunk, size = chunk[:0], 0
+		}
+	}
+
+	if len(rs.runs) == 0 {
+		sort.Slice(chunk, func(i, j int) bool { return rs.less(&chunk[i], &chunk[j]) })
+		for i := range chunk {
+			if err = emit(chunk[i].raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(chunk) > 0 {
+		if err = rs.spill(chunk); err != nil {
+			return err
+		}
+	}
+	for len(rs.runs) > mergeFanIn {
+		if err = rs.mergePass(); err != nil {
+			return err
+		}
+	}
+	return rs.merge(rs.runs, func(it *item) error { return emit(it.raw) })
+}
+
+// spill sorts chunk and writes it to a new run file.
+func (rs *runSorter) spill(chunk []item) error {
+	sort.Slice(chunk, func(i, j int) bool { return rs.less(&chunk[i], &chunk[j]) })
+	return rs.writeRun(func(bw *bufio.Writer) error {
+		for i := range chunk {
+			if err := writeItem(bw, &chunk[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeRun creates a new run file, fills it with write, and closes it.
+func (rs *runSorter) writeRun(write func(bw *bufio.Writer) error) error {
+	f, err := os.CreateTemp(rs.dir, "normalizedsort-*")
+	if err != nil {
+		return err
+	}
+	rs.runs = append(rs.runs, f.Name())
+	bw := bufio.NewWriter(f)
+	if err = write(bw); err == nil {
+		err = bw.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// mergePass merges the runs in groups of mergeFanIn into fewer, longer runs.
+func (rs *runSorter) mergePass() error {
+	old := rs.runs
+	rs.runs = nil
+	defer func() {
+		for _, name := range old {
+			os.Remove(name)
+		}
+	}()
+	for len(old) > 0 {
+		n := mergeFanIn
+		if n > len(old) {
+			n = len(old)
+		}
+		group := old[:n]
+		err := rs.writeRun(func(bw *bufio.Writer) error {
+			return rs.merge(group, func(it *item) error { return writeItem(bw, it) })
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range group {
+			os.Remove(name)
+		}
+		old = old[n:]
+	}
+	return nil
+}
+
+// merge performs a k-way merge of the named runs, passing each item to emit.
+func (rs *runSorter) merge(runs []string, emit func(*item) error) error {
+	h := runHeap{less: rs.less}
+	for _, name := range runs {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		rr := &runReader{r: bufio.NewReader(f)}
+		ok, err := rr.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.readers = append(h.readers, rr)
+		}
+	}
+	heap.Init(&h)
+	for h.Len() > 0 {
+		rr := h.readers[0]
+		if err := emit(&rr.cur); err != nil {
+			return err
+		}
+		ok, err := rr.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return nil
+}
+
+// Run files hold items as uvarint-length-prefixed key and raw strings.
+
+func writeItem(bw *bufio.Writer, it *item) error {
+	var buf [binary.MaxVarintLen64]byte
+	for _, s := range [2]string{it.key, it.raw} {
+		n := binary.PutUvarint(buf[:], uint64(len(s)))
+		if _, err := bw.Write(buf[:n]); err != nil {
+			return err
+		}
+		if _, err := bw.WriteString(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type runReader struct {
+	r   *bufio.Reader
+	cur item
+}
+
+// next reads the following item into cur, reporting false at the end of the run.
+func (rr *runReader) next() (bool, error) {
+	key, err := rr.readString()
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	raw, err := rr.readString()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return false, err
+	}
+	rr.cur = item{key, raw}
+	return true, nil
+}
+
+func (rr *runReader) readString() (string, error) {
+	n, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err = io.ReadFull(rr.r, buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	return string(buf), nil
+}
+
+type runHeap struct {
+	readers []*runReader
+	less    func(a, b *item) bool
+}
+
+func (h *runHeap) Len() int           { return len(h.readers) }
+func (h *runHeap) Less(i, j int) bool { return h.less(&h.readers[i].cur, &h.readers[j].cur) }
+func (h *runHeap) Swap(i, j int)      { h.readers[i], h.readers[j] = h.readers[j], h.readers[i] }
+func (h *runHeap) Push(x interface{}) { h.readers = append(h.readers, x.(*runReader)) }
+func (h *runHeap) Pop() interface{} {
+	rr := h.readers[len(h.readers)-1]
+	h.readers = h.readers[:len(h.readers)-1]
+	return rr
+}
@@ -0,0 +1,141 @@
+package normalizedsort_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func ExampleExternalSorter() {
+	in := strings.NewReader("banana\nApple\ncherry\napple\n")
+	es := normalizedsort.ExternalSorter{MemoryLimit: 1 << 20}
+	if err := es.Sort(os.Stdout, in); err != nil {
+		panic(err)
+	}
+	// Output:
+	// Apple
+	// apple
+	// banana
+	// cherry
+}
+
+func randomLines(n int) []string {
+	r := rand.New(rand.NewSource(1))
+	const letters = "aAbBcC dD1é"
+	lines := make([]string, n)
+	for i := range lines {
+		b := make([]rune, r.Intn(12))
+		for j := range b {
+			b[j] = []rune(letters)[r.Intn(len([]rune(letters)))]
+		}
+		lines[i] = string(b)
+	}
+	return lines
+}
+
+func TestExternalSorter(t *testing.T) {
+	lines := randomLines(5000)
+	for _, tc := range []struct {
+		name  string
+		es    normalizedsort.ExternalSorter
+		limit int
+		sort  func([]string)
+	}{
+		{"in-memory", normalizedsort.ExternalSorter{}, 0,
+			func(ss []string) { normalizedsort.Sort(ss, nil) }},
+		{"one-pass", normalizedsort.ExternalSorter{Normalize: normalizedsort.CaseInsensitiveTrimSpace}, 20000,
+			func(ss []string) { normalizedsort.Sort(ss, normalizedsort.CaseInsensitiveTrimSpace) }},
+		{"multi-pass", normalizedsort.ExternalSorter{}, 1000,
+			func(ss []string) { normalizedsort.Sort(ss, nil) }},
+		{"compare", normalizedsort.ExternalSorter{Compare: strings.Compare}, 1000,
+			func(ss []string) { normalizedsort.SortFunc(ss, strings.Compare) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tc.es.TempDir = dir
+			tc.es.MemoryLimit = tc.limit
+
+			var out bytes.Buffer
+			in := strings.Join(lines, "\n")
+			if err := tc.es.Sort(&out, strings.NewReader(in)); err != nil {
+				t.Fatal(err)
+			}
+			want := append([]string(nil), lines...)
+			tc.sort(want)
+			if got := out.String(); got != strings.Join(want, "\n")+"\n" {
+				t.Errorf("output does not match Sort")
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("temporary files left behind: %d", len(entries))
+			}
+		})
+	}
+}
+
+// fdCounter checks the number of open files of the process each time it
+// is read from or written to.
+type fdCounter struct {
+	r    io.Reader
+	w    io.Writer
+	base int
+	max  int
+}
+
+func openFiles() int {
+	entries, _ := os.ReadDir("/proc/self/fd")
+	return len(entries)
+}
+
+func (c *fdCounter) check() {
+	if n := openFiles() - c.base; n > c.max {
+		c.max = n
+	}
+}
+
+func (c *fdCounter) Read(p []byte) (int, error) {
+	c.check()
+	return c.r.Read(p)
+}
+
+func (c *fdCounter) Write(p []byte) (int, error) {
+	c.check()
+	return c.w.Write(p)
+}
+
+func TestExternalSorterOpenFiles(t *testing.T) {
+	if openFiles() == 0 {
+		t.Skip("cannot count open files")
+	}
+	// About 35 lines fit in each run, so 20000 lines make several hundred,
+	// far more than are merged at once.
+	in := strings.Repeat("abcdefghijklmnopqrstuvwxyz\n", 20000)
+	es := normalizedsort.ExternalSorter{MemoryLimit: 4000, TempDir: t.TempDir()}
+	c := &fdCounter{r: strings.NewReader(in), w: io.Discard, base: openFiles()}
+	if err := es.Sort(c, c); err != nil {
+		t.Fatal(err)
+	}
+	// At most 64 runs are merged at once, plus the output of a merge pass.
+	if c.max > 64+1 {
+		t.Errorf("sort held %d files open at once", c.max)
+	}
+}
+
+func BenchmarkExternalSorter(b *testing.B) {
+	in := strings.Join(randomLines(100000), "\n")
+	for _, limit := range []int{0, 1 << 20} {
+		b.Run(fmt.Sprintf("limit=%d", limit), func(b *testing.B) {
+			es := normalizedsort.ExternalSorter{MemoryLimit: limit, TempDir: b.TempDir()}
+			for i := 0; i < b.N; i++ {
+				if err := es.Sort(io.Discard, strings.NewReader(in)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
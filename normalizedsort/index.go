@@ -0,0 +1,98 @@
+package normalizedsort
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// OtherLabel is the label of index sections for strings that do not
+// begin with a letter, such as "# | A | B | …" in a directory.
+const OtherLabel = "#"
+
+// TrailingLabel is the label of the section of Collation.Index for
+// strings that sort after the alphabet, such as those in other scripts.
+const TrailingLabel = "…"
+
+// A Section is a labeled run of a sorted slice, such as the names under
+// one letter of an A–Z index. It covers ss[Start:End].
+type Section struct {
+	Label      string
+	Start, End int
+}
+
+// Index splits ss, which must be sorted by Sort with normalize, into
+// sections labeled by the upper-case first letter of each normalized
+// string. Strings whose normalized form begins with anything other than
+// a letter are put under OtherLabel, so use a normalizer such as
+// CaseInsensitiveTrimSpace or Title to file strings by their first word.
+// Empty sections are omitted. If normalize is nil, strings.ToLower is
+// used. To file letters with diacritics under their base letter, use a
+// normalizer that removes them, such as a Collation's.
+//
+// Because digits sort before letters and some symbols may sort after
+// them, more than one section may be labeled OtherLabel. Collation.Index
+// avoids this.
+func Index(ss []string, normalize func(string) string) []Section {
+	if normalize == nil {
+		normalize = strings.ToLower
+	}
+	return index(ss, func(s string) string {
+		r, _ := utf8.DecodeRuneInString(normalize(s))
+		if !unicode.IsLetter(r) {
+			return OtherLabel
+		}
+		return string(unicode.ToUpper(r))
+	})
+}
+
+// Index is like the package-level Index for a slice sorted by Sort with
+// c.Normalize, except that the distinct letters of the Collation get
+// sections of their own, after their base letter: in Spanish, "Ñ" follows
+// "N", and in Swedish, "Å", "Ä", and "Ö" follow "Z". Strings that sort
+// before the alphabet, such as those beginning with digits, are put
+// under OtherLabel, and strings that sort after it, such as those in
+// other scripts, under TrailingLabel, so that each label names at most
+// one section.
+func (c *Collation) Index(ss []string) []Section {
+	return index(ss, func(s string) string {
+		key := c.Normalize(s)
+		if len(key) >= 3 && key[1] == tailorMark {
+			if r, ok := c.letters[tailoring{key[0], key[2]}]; ok {
+				return strings.ToUpper(string(r))
+			}
+		}
+		switch {
+		case key == "" || key[0] < 'a':
+			return OtherLabel
+		case key[0] > 'z':
+			return TrailingLabel
+		}
+		return strings.ToUpper(key[:1])
+	})
+}
+
+// Labels returns the labels of every possible section of c.Index in
+// order: OtherLabel, the letters, and TrailingLabel, e.g. for drawing a
+// complete A–Z bar.
+func (c *Collation) Labels() []string {
+	labels := make([]string, 0, len(c.alphabet)+2)
+	labels = append(labels, OtherLabel)
+	for _, l := range c.alphabet {
+		labels = append(labels, strings.ToUpper(l))
+	}
+	return append(labels, TrailingLabel)
+}
+
+func index(ss []string, label func(string) string) []Section {
+	var sections []Section
+	for i, s := range ss {
+		l := label(s)
+		if n := len(sections); n > 0 && sections[n-1].Label == l {
+			sections[n-1].End = i + 1
+			continue
+		}
+		sections = append(sections, Section{l, i, i + 1})
+	}
+	return sections
+}
@@ -0,0 +1,45 @@
+package normalizedsort_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func ExampleCollation_Index() {
+	es, _ := normalizedsort.NewCollation("es")
+	names := []string{"Ñúñez", "Nadal", "Ortega", "4 Non Blondes", "núcleo", "Álvarez", "Ñandú"}
+	normalizedsort.Sort(names, es.Normalize)
+	for _, sec := range es.Index(names) {
+		fmt.Printf("%s %q\n", sec.Label, names[sec.Start:sec.End])
+	}
+	// Output:
+	// # ["4 Non Blondes"]
+	// A ["Álvarez"]
+	// N ["Nadal" "núcleo"]
+	// Ñ ["Ñandú" "Ñúñez"]
+	// O ["Ortega"]
+}
+
+func TestIndex(t *testing.T) {
+	names := []string{"Åsa", "Anna", "\"Zelda\"", "Öberg", "Édith", "Борис", "12 Monkeys", "Ärla"}
+	sv, _ := normalizedsort.NewCollation("sv")
+	normalizedsort.Sort(names, sv.Normalize)
+	got := fmt.Sprint(sv.Index(names))
+	want := "[{# 0 2} {A 2 3} {E 3 4} {Å 4 5} {Ä 5 6} {Ö 6 7} {… 7 8}]"
+	if got != want {
+		t.Errorf("sv.Index(%q) = %s; want %s", names, got, want)
+	}
+
+	normalizedsort.Sort(names, nil)
+	got = fmt.Sprint(normalizedsort.Index(names, nil))
+	want = "[{# 0 2} {A 2 3} {Ä 3 4} {Å 4 5} {É 5 6} {Ö 6 7} {Б 7 8}]"
+	if got != want {
+		t.Errorf("Index(%q) = %s; want %s", names, got, want)
+	}
+
+	if labels := sv.Labels(); len(labels) != 31 || labels[0] != "#" || labels[27] != "Å" || labels[30] != "…" {
+		t.Errorf("sv.Labels() = %q", labels)
+	}
+}
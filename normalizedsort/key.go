@@ -0,0 +1,93 @@
+package normalizedsort
+
+import (
+	"errors"
+	"strings"
+)
+
+// Keys are encoded as the normalized form with each 0x00 byte escaped as
+// 0x00 0xFF, a 0x00 0x01 terminator, and then the raw form verbatim.
+// The terminator sorts before any escaped byte, so a normalized form
+// sorts before all of its extensions and the raw form acts only as the
+// tie-breaker, as in New.
+const (
+	keyEscape     = 0x00
+	keyEscaped    = 0xff
+	keyTerminator = 0x01
+)
+
+// ErrInvalidKey is returned by DecodeKey for malformed keys.
+var ErrInvalidKey = errors.New("normalizedsort: invalid key")
+
+// Key returns a binary sort key for s using strings.ToLower, the default
+// normalization of New. See KeyFunc.
+func Key(s string) []byte {
+	return appendKey(nil, strings.ToLower(s), s)
+}
+
+// KeyFunc returns a function that encodes strings as binary sort keys for
+// normalize, e.g. for use in a byte-ordered key-value store. For any a and
+// b, bytes.Compare(key(a), key(b)) < 0 exactly when a sorts before b in a
+// sort.Interface returned by New(ss, normalize). If normalize is nil,
+// strings.ToLower is used. The original string can be recovered from a
+// key with DecodeKey.
+//
+// There is no equivalent for the comparison functions of SortFunc, whose
+// orderings cannot in general be expressed as byte strings.
+func KeyFunc(normalize func(string) string) func(string) []byte {
+	if normalize == nil {
+		normalize = strings.ToLower
+	}
+	return func(s string) []byte {
+		return appendKey(nil, normalize(s), s)
+	}
+}
+
+// Key returns a binary sort key for s in the Collation. See KeyFunc.
+func (c *Collation) Key(s string) []byte {
+	return appendKey(nil, c.Normalize(s), s)
+}
+
+func appendKey(dst []byte, normalized, raw string) []byte {
+	if dst == nil {
+		dst = make([]byte, 0, len(normalized)+len(raw)+2)
+	}
+	dst = appendEscaped(dst, normalized)
+	return append(append(dst, keyEscape, keyTerminator), raw...)
+}
+
+// appendEscaped appends s to dst with each 0x00 byte escaped.
+func appendEscaped(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		dst = append(dst, s[i])
+		if s[i] == keyEscape {
+			dst = append(dst, keyEscaped)
+		}
+	}
+	return dst
+}
+
+// DecodeKey returns the original string encoded in a key returned by Key,
+// KeyFunc, or Collation.Key, along with its normalized form.
+func DecodeKey(key []byte) (raw, normalized string, err error) {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		if key[i] != keyEscape {
+			b.WriteByte(key[i])
+			continue
+		}
+		if i+1 == len(key) {
+			break
+		}
+		i++
+		switch key[i] {
+		case keyEscaped:
+			b.WriteByte(keyEscape)
+		case keyTerminator:
+			return string(key[i+1:]), b.String(), nil
+		default:
+			return "", "", ErrInvalidKey
+		}
+	}
+	return "", "", ErrInvalidKey
+}
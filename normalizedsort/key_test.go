@@ -0,0 +1,80 @@
+package normalizedsort_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func ExampleKeyFunc() {
+	key := normalizedsort.KeyFunc(normalizedsort.Title)
+	fmt.Println(bytes.Compare(key("The Beatles"), key("Abba")))
+	fmt.Println(bytes.Compare(key("The Beatles"), key("the beatles")))
+	raw, normalized, _ := normalizedsort.DecodeKey(key("The Beatles"))
+	fmt.Printf("%q %q\n", raw, normalized)
+	// Output:
+	// 1
+	// -1
+	// "The Beatles" "beatles"
+}
+
+func TestKeyFunc(t *testing.T) {
+	sv, err := normalizedsort.NewCollation("sv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(1))
+	alphabet := []rune("aAbB 0129\x00\x01\xffåÄöÖz-,.")
+	randomString := func() string {
+		rs := make([]rune, r.Intn(8))
+		for i := range rs {
+			rs[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		return string(rs)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		normalize func(string) string
+		key       func(string) []byte
+	}{
+		{"default", nil, normalizedsort.Key},
+		{"CaseInsensitiveTrimSpace", normalizedsort.CaseInsensitiveTrimSpace, nil},
+		{"Title", normalizedsort.Title, nil},
+		{"Name", normalizedsort.Name, nil},
+		{"Natural", normalizedsort.Natural, nil},
+		{"FoldDiacritics", normalizedsort.FoldDiacritics, nil},
+		{"Collation", sv.Normalize, sv.Key},
+	} {
+		key := tc.key
+		if key == nil {
+			key = normalizedsort.KeyFunc(tc.normalize)
+		}
+		for i := 0; i < 10000; i++ {
+			a, b := randomString(), randomString()
+			if i%10 == 0 {
+				b = a + randomString()
+			}
+			ss := []string{a, b}
+			less := normalizedsort.New(ss, tc.normalize).Less(0, 1)
+			more := normalizedsort.New(ss, tc.normalize).Less(1, 0)
+			c := bytes.Compare(key(a), key(b))
+			if less && c >= 0 || more && c <= 0 || !less && !more && c != 0 {
+				t.Fatalf("%s: %q vs %q: Less = %v, %v; bytes.Compare = %d",
+					tc.name, a, b, less, more, c)
+			}
+			if raw, _, err := normalizedsort.DecodeKey(key(a)); err != nil || raw != a {
+				t.Fatalf("%s: DecodeKey(key(%q)) = %q, %v", tc.name, a, raw, err)
+			}
+		}
+	}
+
+	for _, bad := range []string{"", "abc", "a\x00", "a\x00\x02b"} {
+		if _, _, err := normalizedsort.DecodeKey([]byte(bad)); err != normalizedsort.ErrInvalidKey {
+			t.Errorf("DecodeKey(%q) = %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package normalizedsort
+
+import (
+	"container/heap"
+	"sort"
+	"strings"
+)
+
+// TopK returns the first k strings of ss in the order of New with normalize,
+// without sorting all of ss. It keeps the k best strings seen so far in a
+// bounded heap, so it normalizes each string once and runs in O(n log k)
+// time. ss is not modified. If normalize is nil, strings.ToLower is used.
+func TopK(ss []string, k int, normalize func(string) string) []string {
+	if normalize == nil {
+		normalize = strings.ToLower
+	}
+	if k > len(ss) {
+		k = len(ss)
+	}
+	if k <= 0 {
+		return nil
+	}
+	// h is a max-heap: its root is the worst of the best k.
+	h := maxHeap{normalizedStringSlice{
+		original:   make([]string, 0, k),
+		normalized: make([]string, 0, k),
+	}}
+	for _, s := range ss {
+		key := normalize(s)
+		if h.Len() < k {
+			heap.Push(&h, item{key, s})
+			continue
+		}
+		if keyLess(&item{key, s}, &item{h.normalized[0], h.original[0]}) {
+			h.original[0], h.normalized[0] = s, key
+			heap.Fix(&h, 0)
+		}
+	}
+	sort.Sort(&h.normalizedStringSlice)
+	return h.original
+}
+
+type maxHeap struct {
+	normalizedStringSlice
+}
+
+func (h *maxHeap) Less(i, j int) bool {
+	return h.normalizedStringSlice.Less(j, i)
+}
+
+func (h *maxHeap) Push(x interface{}) {
+	it := x.(item)
+	h.original = append(h.original, it.raw)
+	h.normalized = append(h.normalized, it.key)
+}
+
+func (h *maxHeap) Pop() interface{} {
+	n := len(h.original) - 1
+	it := item{h.normalized[n], h.original[n]}
+	h.original, h.normalized = h.original[:n], h.normalized[:n]
+	return it
+}
+
+// An Iterator produces a sequence of strings, such as the results of a
+// query against one shard of a sharded index.
+type Iterator interface {
+	// Next advances the Iterator to the next string, reporting false
+	// when there are no more.
+	Next() bool
+	// Value returns the current string.
+	Value() string
+}
+
+// SliceIterator returns an Iterator over ss.
+func SliceIterator(ss []string) Iterator {
+	return &sliceIterator{ss: ss, i: -1}
+}
+
+type sliceIterator struct {
+	ss []string
+	i  int
+}
+
+func (it *sliceIterator) Next() bool {
+	if it.i+1 >= len(it.ss) {
+		it.i = len(it.ss)
+		return false
+	}
+	it.i++
+	return true
+}
+
+func (it *sliceIterator) Value() string {
+	return it.ss[it.i]
+}
+
+// Merge merges lists, each already sorted by Sort with normalize, into a
+// single sorted slice. Strings that normalize alike are ordered by their
+// raw form, as in New. If normalize is nil, strings.ToLower is used.
+func Merge(normalize func(string) string, lists ...[]string) []string {
+	n := 0
+	its := make([]Iterator, len(lists))
+	for i, l := range lists {
+		n += len(l)
+		its[i] = SliceIterator(l)
+	}
+	out := make([]string, 0, n)
+	for m := MergeIterators(normalize, its...); m.Next(); {
+		out = append(out, m.Value())
+	}
+	return out
+}
+
+// MergeIterators returns an Iterator that lazily merges its inputs, each
+// of which must produce strings in the order of Sort with normalize. Each
+// input is advanced only when its current string has been consumed. If
+// normalize is nil, strings.ToLower is used.
+func MergeIterators(normalize func(string) string, its ...Iterator) Iterator {
+	if normalize == nil {
+		normalize = strings.ToLower
+	}
+	return &mergeIterator{normalize: normalize, pending: append([]Iterator(nil), its...)}
+}
+
+type mergeIterator struct {
+	normalize func(string) string
+	// pending holds inputs not yet advanced to their next value.
+	pending []Iterator
+	heap    iteratorHeap
+	cur     string
+}
+
+type iteratorHeap []mergeSource
+
+type mergeSource struct {
+	item
+	it Iterator
+}
+
+func (h iteratorHeap) Len() int            { return len(h) }
+func (h iteratorHeap) Less(i, j int) bool  { return keyLess(&h[i].item, &h[j].item) }
+func (h iteratorHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *iteratorHeap) Push(x interface{}) { *h = append(*h, x.(mergeSource)) }
+func (h *iteratorHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+func (m *mergeIterator) Next() bool {
+	for _, it := range m.pending {
+		if it.Next() {
+			v := it.Value()
+			heap.Push(&m.heap, mergeSource{item{m.normalize(v), v}, it})
+		}
+	}
+	m.pending = m.pending[:0]
+	if len(m.heap) == 0 {
+		return false
+	}
+	src := heap.Pop(&m.heap).(mergeSource)
+	m.cur = src.raw
+	m.pending = append(m.pending, src.it)
+	return true
+}
+
+func (m *mergeIterator) Value() string {
+	return m.cur
+}
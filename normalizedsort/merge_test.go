@@ -0,0 +1,78 @@
+package normalizedsort_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func ExampleTopK() {
+	names := []string{"delta", "Alpha", "charlie", "alpha", "Bravo", "echo"}
+	fmt.Printf("%q\n", normalizedsort.TopK(names, 3, nil))
+	// Output: ["Alpha" "alpha" "Bravo"]
+}
+
+func ExampleMerge() {
+	shard1 := []string{"Apple", "cherry", "Fig"}
+	shard2 := []string{"apple", "banana", "grape"}
+	fmt.Printf("%q\n", normalizedsort.Merge(nil, shard1, shard2))
+	// Output: ["Apple" "apple" "banana" "cherry" "Fig" "grape"]
+}
+
+func TestTopK(t *testing.T) {
+	lines := randomLines(2000)
+	want := append([]string(nil), lines...)
+	normalizedsort.Sort(want, normalizedsort.Natural)
+	for _, k := range []int{-1, 0, 1, 20, 1999, 2000, 5000} {
+		got := normalizedsort.TopK(lines, k, normalizedsort.Natural)
+		n := k
+		if n < 0 {
+			n = 0
+		} else if n > len(want) {
+			n = len(want)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want[:n]) {
+			t.Errorf("TopK(%d) = %q; want %q", k, got, want[:n])
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	lines := randomLines(3000)
+	want := append([]string(nil), lines...)
+	normalizedsort.Sort(want, nil)
+
+	var lists [][]string
+	for i := 0; i < len(lines); i += 700 {
+		end := i + 700
+		if end > len(lines) {
+			end = len(lines)
+		}
+		l := append([]string(nil), lines[i:end]...)
+		normalizedsort.Sort(l, nil)
+		lists = append(lists, l, nil)
+	}
+	got := normalizedsort.Merge(nil, lists...)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Error("Merge does not match Sort")
+	}
+}
+
+func TestMergeIterators(t *testing.T) {
+	its := []normalizedsort.Iterator{
+		normalizedsort.SliceIterator([]string{"b", "d"}),
+		normalizedsort.SliceIterator([]string{"a", "c"}),
+	}
+	first, second := its[0], its[1]
+	var got []string
+	for m := normalizedsort.MergeIterators(nil, its...); m.Next(); {
+		if its[0] != first || its[1] != second {
+			t.Fatal("MergeIterators modified its arguments")
+		}
+		got = append(got, m.Value())
+	}
+	if fmt.Sprint(got) != "[a b c d]" {
+		t.Errorf("MergeIterators = %q", got)
+	}
+}
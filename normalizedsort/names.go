@@ -0,0 +1,235 @@
+package normalizedsort
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Articles lists the leading articles ignored by TitleFunc, keyed by
+// ISO 639-1 language code. Articles that elide into the following word,
+// such as French "l'", end with an apostrophe.
+var Articles = map[string][]string{
+	"da": {"den", "det", "de", "en", "et"},
+	"de": {"der", "die", "das", "des", "dem", "den", "ein", "eine"},
+	"en": {"the", "a", "an"},
+	"es": {"el", "la", "los", "las", "un", "una"},
+	"fr": {"le", "la", "les", "l'", "un", "une"},
+	"it": {"il", "lo", "la", "i", "gli", "le", "l'", "un", "uno", "una", "un'"},
+	"nl": {"de", "het", "'t", "een"},
+	"no": {"den", "det", "de", "en", "ei", "et"},
+	"pt": {"o", "a", "os", "as", "um", "uma"},
+	"sv": {"den", "det", "de", "en", "ett"},
+}
+
+// Title normalizes an English title for library-style filing. It lower-cases s,
+// collapses runs of white space, and drops leading punctuation and a leading
+// "the", "a", or "an", so that "The Beatles" files under B.
+func Title(s string) string {
+	return englishTitle(s)
+}
+
+var englishTitle = TitleFunc(Articles["en"]...)
+
+// TitleFunc returns a title normalizer like Title that drops the given
+// leading articles instead of the English ones. Use it with one or more
+// entries of Articles, e.g. TitleFunc(Articles["fr"]...). A title that
+// consists only of an article is left as is.
+func TitleFunc(articles ...string) func(string) string {
+	arts := make([]string, len(articles))
+	for i, a := range articles {
+		arts[i] = strings.ToLower(normalizeApostrophes(a))
+	}
+	return func(s string) string {
+		s = strings.Join(strings.Fields(strings.ToLower(normalizeApostrophes(s))), " ")
+		s = trimLeadingPunct(s)
+		for _, a := range arts {
+			if !strings.HasPrefix(s, a) {
+				continue
+			}
+			rest := s[len(a):]
+			if !strings.HasSuffix(a, "'") {
+				if !strings.HasPrefix(rest, " ") {
+					continue
+				}
+				rest = rest[1:]
+			}
+			if rest = trimLeadingPunct(rest); rest != "" {
+				return rest
+			}
+		}
+		return s
+	}
+}
+
+var apostrophes = strings.NewReplacer("’", "'", "ʼ", "'")
+
+func normalizeApostrophes(s string) string {
+	return apostrophes.Replace(s)
+}
+
+func trimLeadingPunct(s string) string {
+	return strings.TrimLeftFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// ParticleRule controls how Name files surnames that begin with a
+// particle such as "van", "de", or "von der".
+type ParticleRule int
+
+const (
+	// ParticlesByCase files a surname under its particle if the particle
+	// is capitalized ("Van Buren" under V) and under the word after it
+	// otherwise ("van Gogh" under G). This is the usual English rule.
+	ParticlesByCase ParticleRule = iota
+	// DropParticles files every surname under the word after its particles.
+	DropParticles
+	// KeepParticles files every surname under its first particle.
+	KeepParticles
+)
+
+// nameSep separates the surname, given names, and suffix in a Name key.
+// It sorts before any printable character, so that "Smith, John" comes
+// before "Smith Jones, Al".
+const nameSep = "\x00"
+
+var (
+	nameParticles = map[string]bool{
+		"af": true, "av": true, "d'": true, "da": true, "das": true,
+		"de": true, "degli": true, "dei": true, "del": true, "della": true,
+		"delle": true, "den": true, "der": true, "des": true, "di": true,
+		"dos": true, "du": true, "la": true, "le": true, "ten": true,
+		"ter": true, "van": true, "vom": true, "von": true, "zu": true,
+		"zum": true, "zur": true,
+	}
+	nameSuffixes = map[string]bool{
+		"jr": true, "sr": true, "ii": true, "iii": true, "iv": true,
+	}
+)
+
+// Name normalizes a personal name for filing by surname. It accepts both
+// "Last, First" and "First Last" forms, files surname particles according
+// to ParticlesByCase, files "Mc" and "M'" as "Mac", and ignores
+// apostrophes, periods, and case. The result is a sort key, not a
+// display form.
+func Name(s string) string {
+	return nameByCase(s)
+}
+
+var nameByCase = NameFunc(ParticlesByCase)
+
+// NameFunc returns a name normalizer like Name that files surname
+// particles according to rule.
+func NameFunc(rule ParticleRule) func(string) string {
+	return func(s string) string {
+		surname, given, suffix := splitName(normalizeApostrophes(s))
+		particles := 0
+		for particles < len(surname)-1 && isParticle(surname[particles]) {
+			particles++
+		}
+		keep := rule == KeepParticles ||
+			rule == ParticlesByCase && particles > 0 && startsUpper(surname[0])
+		if particles > 0 && !keep {
+			given = append(given[:len(given):len(given)], surname[:particles]...)
+			surname = surname[particles:]
+		}
+		if len(surname) > 0 {
+			surname[0] = macPrefix(surname[0])
+		}
+		key := nameKey(surname) + nameSep + nameKey(given)
+		if len(suffix) > 0 {
+			key += nameSep + nameKey(suffix)
+		}
+		return key
+	}
+}
+
+// splitName breaks s into surname, given name, and suffix words.
+// Elided particles such as "d'Artagnan" are split into their own word.
+func splitName(s string) (surname, given, suffix []string) {
+	if parts := strings.Split(s, ","); len(parts) > 1 {
+		surname = nameFields(parts[0])
+		given = nameFields(parts[1])
+		for _, part := range parts[2:] {
+			suffix = append(suffix, nameFields(part)...)
+		}
+		// "Gogh, Vincent van": trailing particles belong to the surname.
+		i := len(given)
+		for i > 1 && isParticle(given[i-1]) {
+			i--
+		}
+		surname = append(given[i:len(given):len(given)], surname...)
+		given = given[:i]
+		return surname, given, suffix
+	}
+
+	words := nameFields(s)
+	for len(words) > 1 && nameSuffixes[nameWord(words[len(words)-1])] {
+		suffix = append([]string{words[len(words)-1]}, suffix...)
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 {
+		return nil, nil, suffix
+	}
+	// Never take the first word as a particle: "Van Morrison" is a
+	// given name and a surname.
+	i := len(words) - 1
+	for i > 1 && isParticle(words[i-1]) {
+		i--
+	}
+	return words[i:], words[:i], suffix
+}
+
+func nameFields(s string) []string {
+	var words []string
+	for _, w := range strings.Fields(s) {
+		if len(w) > 2 && (w[:2] == "d'" || w[:2] == "D'") {
+			words = append(words, w[:2], w[2:])
+			continue
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+func isParticle(w string) bool {
+	return nameParticles[strings.ToLower(w)]
+}
+
+func startsUpper(w string) bool {
+	for _, r := range w {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+func macPrefix(w string) string {
+	lw := strings.ToLower(w)
+	switch {
+	case strings.HasPrefix(lw, "mc") && len(lw) > 2:
+		return "mac" + w[2:]
+	case strings.HasPrefix(lw, "m'") && len(lw) > 2:
+		return "mac" + w[2:]
+	}
+	return w
+}
+
+// nameWord lower-cases w and removes the punctuation ignored in filing.
+func nameWord(w string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\'' || r == '.' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, w)
+}
+
+func nameKey(words []string) string {
+	out := make([]string, 0, len(words))
+	for _, w := range words {
+		if w = nameWord(w); w != "" {
+			out = append(out, w)
+		}
+	}
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,67 @@
+package normalizedsort_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func ExampleTitle() {
+	slice := []string{"The Beatles", "Abba", "A Tribe Called Quest", "\"Weird Al\" Yankovic", "The The"}
+	normalizedsort.Sort(slice, normalizedsort.Title)
+	fmt.Printf("%q\n", slice)
+	// Output: ["Abba" "The Beatles" "The The" "A Tribe Called Quest" "\"Weird Al\" Yankovic"]
+}
+
+func ExampleTitleFunc() {
+	slice := []string{"Les Misérables", "L'Avare", "Le Petit Prince", "Candide"}
+	normalizedsort.Sort(slice, normalizedsort.TitleFunc(normalizedsort.Articles["fr"]...))
+	fmt.Printf("%q\n", slice)
+	// Output: ["L'Avare" "Candide" "Les Misérables" "Le Petit Prince"]
+}
+
+func ExampleName() {
+	slice := []string{"Vincent van Gogh", "Van Buren, Martin", "Gauguin, Paul", "McCartney, Paul", "Macaulay, Rose"}
+	normalizedsort.Sort(slice, normalizedsort.Name)
+	fmt.Printf("%q\n", slice)
+	// Output: ["Gauguin, Paul" "Vincent van Gogh" "Macaulay, Rose" "McCartney, Paul" "Van Buren, Martin"]
+}
+
+func TestName(t *testing.T) {
+	for _, tc := range []struct {
+		rule normalizedsort.ParticleRule
+		a, b string
+	}{
+		{normalizedsort.ParticlesByCase, "van Gogh, Vincent", "Vincent van Gogh"},
+		{normalizedsort.ParticlesByCase, "Gogh, Vincent van", "Vincent van Gogh"},
+		{normalizedsort.ParticlesByCase, "d'Artagnan, Charles", "Charles d’Artagnan"},
+		{normalizedsort.ParticlesByCase, "King, Martin Luther, Jr.", "Martin Luther King Jr"},
+		{normalizedsort.ParticlesByCase, "O'Brien, Flann", "Flann O’Brien"},
+		{normalizedsort.ParticlesByCase, "MacDonald, Ross", "Ross McDonald"},
+		{normalizedsort.DropParticles, "Van Buren, Martin", "Buren, Martin Van"},
+		{normalizedsort.KeepParticles, "van Gogh, Vincent", "Van Gogh, Vincent"},
+	} {
+		normalize := normalizedsort.NameFunc(tc.rule)
+		if a, b := normalize(tc.a), normalize(tc.b); a != b {
+			t.Errorf("%q => %q; %q => %q", tc.a, a, tc.b, b)
+		}
+	}
+
+	for _, tc := range []struct {
+		rule       normalizedsort.ParticleRule
+		less, more string
+	}{
+		{normalizedsort.ParticlesByCase, "Vincent van Gogh", "Van Buren, Martin"},
+		{normalizedsort.ParticlesByCase, "Van Buren, Martin", "Vonnegut, Kurt"},
+		{normalizedsort.KeepParticles, "Vincent van Gogh", "Vonnegut, Kurt"},
+		{normalizedsort.KeepParticles, "Van Buren, Martin", "Vincent van Gogh"},
+		{normalizedsort.ParticlesByCase, "Smith, John", "Smith Jones, Al"},
+		{normalizedsort.ParticlesByCase, "Van Morrison", "Nico"},
+	} {
+		normalize := normalizedsort.NameFunc(tc.rule)
+		if a, b := normalize(tc.less), normalize(tc.more); a >= b {
+			t.Errorf("want %q < %q; got %q >= %q", tc.less, tc.more, a, b)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package normalizedsort
+
+import "strings"
+
+// Natural normalizes s so that runs of ASCII digits sort by numeric value
+// instead of digit by digit, e.g. "file2" before "file10". Leading zeros
+// are ignored, so "007" and "7" normalize alike and are ordered by their
+// raw form. The result is a sort key, not a display form.
+func Natural(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 4)
+	for i := 0; i < len(s); {
+		if !isDigit(s[i]) {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+		digits := strings.TrimLeft(s[i:j], "0")
+		writeDigitCount(&b, len(digits))
+		b.WriteString(digits)
+		i = j
+	}
+	return b.String()
+}
+
+// writeDigitCount writes n as a prefix of digit characters that orders
+// like n and is never a prefix of another count: 0 through 8 are written
+// as a single digit and larger counts as a '9' followed by the count of n-9.
+// Since the prefix is made of digits, numbers still sort before letters.
+func writeDigitCount(b *strings.Builder, n int) {
+	for n >= 9 {
+		b.WriteByte('9')
+		n -= 9
+	}
+	b.WriteByte(byte('0' + n))
+}
@@ -0,0 +1,324 @@
+package normalizedsort
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A NumberFormat compares strings containing formatted numbers, such as
+// "1,234.50", "-3", "1e6", and "12%", by numeric value. Text between
+// numbers is compared case-insensitively, and numbers sort before text,
+// as with Natural.
+type NumberFormat struct {
+	// Locale is the language code of the format, such as "de".
+	Locale string
+	// Thousands lists the characters that may separate groups of three
+	// digits in the integer part of a number. A separator only counts if
+	// it is followed by exactly three digits, so "1,5" is not 15.
+	Thousands string
+	// Decimal separates the integer and fractional parts of a number.
+	Decimal rune
+	// Roman enables Roman numerals: words of upper-case I, V, X, L, C, D,
+	// and M in canonical form, such as "IV" or "MCMXCIX", compare as
+	// numbers. It is off by default, since words like "I" and "MIX" are
+	// more often not numbers.
+	Roman bool
+}
+
+// numberFormats holds the thousands and decimal separators of each locale.
+var numberFormats = map[string]struct {
+	thousands string
+	decimal   rune
+}{
+	"cs":    {" \u00a0\u202f", ','},
+	"da":    {".", ','},
+	"de":    {".", ','},
+	"de-ch": {"'’", '.'},
+	"en":    {",", '.'},
+	"es":    {".", ','},
+	"fi":    {" \u00a0\u202f", ','},
+	"fr":    {" \u00a0\u202f", ','},
+	"it":    {".", ','},
+	"ja":    {",", '.'},
+	"nl":    {".", ','},
+	"no":    {" \u00a0\u202f", ','},
+	"pl":    {" \u00a0\u202f", ','},
+	"pt":    {".", ','},
+	"ru":    {" \u00a0\u202f", ','},
+	"sv":    {" \u00a0\u202f", ','},
+	"tr":    {".", ','},
+	"zh":    {",", '.'},
+}
+
+// NewNumberFormat returns the NumberFormat for locale, a language code
+// such as "en" or "fr", optionally with a region, as in "de-CH". Regions
+// without their own format use that of their language.
+func NewNumberFormat(locale string) (*NumberFormat, error) {
+	lang := strings.ToLower(strings.Replace(locale, "_", "-", -1))
+	nf, ok := numberFormats[lang]
+	if !ok {
+		if i := strings.IndexByte(lang, '-'); i >= 0 {
+			lang = lang[:i]
+		}
+		nf, ok = numberFormats[lang]
+	}
+	if !ok {
+		return nil, fmt.Errorf("normalizedsort: no number format for locale %q", locale)
+	}
+	return &NumberFormat{Locale: lang, Thousands: nf.thousands, Decimal: nf.decimal}, nil
+}
+
+var englishNumbers, _ = NewNumberFormat("en")
+
+// CompareNumeric compares a and b with the English NumberFormat, without
+// Roman numerals. It is suitable as the cmp argument of SortFunc.
+func CompareNumeric(a, b string) int {
+	return englishNumbers.Compare(a, b)
+}
+
+// Compare compares a and b segment by segment, returning -1, 0, or +1.
+// Numbers are compared by exact value, so "1,000" and "1000.0" are equal,
+// and text is compared as by CompareFold. A sign counts only at the start
+// of a word, so the dashes in "2024-01-05" separate numbers rather than
+// negate them.
+func (f *NumberFormat) Compare(a, b string) int {
+	sa := numberScanner{f: f, s: a}
+	sb := numberScanner{f: f, s: b}
+	for {
+		x, okA := sa.next()
+		y, okB := sb.next()
+		switch {
+		case !okA && !okB:
+			return 0
+		case !okA:
+			return -1
+		case !okB:
+			return 1
+		case x.isNumber != y.isNumber:
+			if x.isNumber {
+				return -1
+			}
+			return 1
+		}
+		c := 0
+		if x.isNumber {
+			c = compareNumbers(x.number, y.number)
+		} else {
+			c = CompareFold(x.text, y.text)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+}
+
+// A number is the exact value 0.digits × 10^exp, negated if neg. Digits
+// has no leading or trailing zeros and is empty for zero.
+type number struct {
+	neg    bool
+	digits string
+	exp    int
+}
+
+func compareNumbers(x, y number) int {
+	sx, sy := x.sign(), y.sign()
+	if sx != sy || sx == 0 {
+		return sign(sx - sy)
+	}
+	c := 0
+	switch {
+	case x.exp != y.exp:
+		c = sign(x.exp - y.exp)
+	default:
+		c = strings.Compare(x.digits, y.digits)
+	}
+	if x.neg {
+		c = -c
+	}
+	return c
+}
+
+func (n number) sign() int {
+	switch {
+	case n.digits == "":
+		return 0
+	case n.neg:
+		return -1
+	}
+	return 1
+}
+
+// maxExp bounds exponents so that they cannot overflow.
+const maxExp = 1 << 30
+
+// newNumber returns the number with the given integer and fractional
+// digits, scaled by 10^exp.
+func newNumber(neg bool, intDigits, fracDigits string, exp int) number {
+	all := intDigits + fracDigits
+	exp += len(intDigits)
+	trimmed := strings.TrimLeft(all, "0")
+	exp -= len(all) - len(trimmed)
+	trimmed = strings.TrimRight(trimmed, "0")
+	if trimmed == "" {
+		return number{}
+	}
+	return number{neg, trimmed, exp}
+}
+
+type segment struct {
+	isNumber bool
+	number   number
+	text     string
+}
+
+// numberScanner splits a string into numbers and the text between them.
+type numberScanner struct {
+	f *NumberFormat
+	s string
+	i int
+}
+
+func (sc *numberScanner) next() (segment, bool) {
+	if sc.i >= len(sc.s) {
+		return segment{}, false
+	}
+	if n, end, ok := sc.number(sc.i); ok {
+		sc.i = end
+		return segment{isNumber: true, number: n}, true
+	}
+	start := sc.i
+	for {
+		_, size := utf8.DecodeRuneInString(sc.s[sc.i:])
+		sc.i += size
+		if sc.i >= len(sc.s) {
+			break
+		}
+		if _, _, ok := sc.number(sc.i); ok {
+			break
+		}
+	}
+	return segment{text: sc.s[start:sc.i]}, true
+}
+
+// number parses a number starting at byte i.
+func (sc *numberScanner) number(i int) (n number, end int, ok bool) {
+	s := sc.s
+	wordStart := i == 0
+	if !wordStart {
+		prev, _ := utf8.DecodeLastRuneInString(s[:i])
+		wordStart = !isWordRune(prev)
+	}
+	if sc.f.Roman && wordStart {
+		if n, end, ok = parseRoman(s, i); ok {
+			return n, end, true
+		}
+	}
+
+	j := i
+	neg := false
+	if r, size := utf8.DecodeRuneInString(s[j:]); wordStart && (r == '-' || r == '+' || r == '−') &&
+		j+size < len(s) && isDigit(s[j+size]) {
+		neg = r != '+'
+		j += size
+	}
+	if j >= len(s) || !isDigit(s[j]) {
+		return number{}, i, false
+	}
+
+	var intPart strings.Builder
+	k := digitsEnd(s, j)
+	intPart.WriteString(s[j:k])
+	if k-j <= 3 && sc.f.Thousands != "" {
+		for {
+			r, size := utf8.DecodeRuneInString(s[k:])
+			if !strings.ContainsRune(sc.f.Thousands, r) || digitsEnd(s, k+size) != k+size+3 {
+				break
+			}
+			intPart.WriteString(s[k+size : k+size+3])
+			k += size + 3
+		}
+	}
+	j = k
+
+	frac := ""
+	if r, size := utf8.DecodeRuneInString(s[j:]); r == sc.f.Decimal && j+size < len(s) && isDigit(s[j+size]) {
+		k = digitsEnd(s, j+size)
+		frac = s[j+size : k]
+		j = k
+	}
+
+	exp := 0
+	if j < len(s) && (s[j] == 'e' || s[j] == 'E') {
+		k = j + 1
+		if k < len(s) && (s[k] == '-' || s[k] == '+') {
+			k++
+		}
+		if k < len(s) && isDigit(s[k]) {
+			end := digitsEnd(s, k)
+			var err error
+			exp, err = strconv.Atoi(s[j+1 : end])
+			if err != nil || exp > maxExp || exp < -maxExp {
+				exp = maxExp
+				if s[j+1] == '-' {
+					exp = -maxExp
+				}
+			}
+			j = end
+		}
+	}
+	return newNumber(neg, intPart.String(), frac, exp), j, true
+}
+
+func digitsEnd(s string, i int) int {
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+var romanValues = [...]struct {
+	value  int
+	symbol string
+}{
+	{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+	{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+}
+
+// parseRoman parses a word of s starting at i as a canonical Roman
+// numeral from I to MMMCMXCIX.
+func parseRoman(s string, i int) (n number, end int, ok bool) {
+	end = i
+	for end < len(s) && strings.IndexByte("IVXLCDM", s[end]) >= 0 {
+		end++
+	}
+	if end == i {
+		return number{}, i, false
+	}
+	if r, _ := utf8.DecodeRuneInString(s[end:]); end < len(s) && isWordRune(r) {
+		return number{}, i, false
+	}
+	word, value := s[i:end], 0
+	for _, rv := range romanValues {
+		for strings.HasPrefix(word, rv.symbol) && value < 4000 {
+			value += rv.value
+			word = word[len(rv.symbol):]
+		}
+	}
+	if word != "" || value >= 4000 || toRoman(value) != s[i:end] {
+		return number{}, i, false
+	}
+	return newNumber(false, strconv.Itoa(value), "", 0), end, true
+}
+
+func toRoman(n int) string {
+	var b strings.Builder
+	for _, rv := range romanValues {
+		for n >= rv.value {
+			b.WriteString(rv.symbol)
+			n -= rv.value
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package normalizedsort_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func ExampleCompareNumeric() {
+	prices := []string{"$1,234.50", "$99", "$-3", "$1e3", "$12.5%", "$1,000", "free"}
+	normalizedsort.SortFunc(prices, normalizedsort.CompareNumeric)
+	fmt.Printf("%q\n", prices)
+	// Output: ["$-3" "$12.5%" "$99" "$1,000" "$1e3" "$1,234.50" "free"]
+}
+
+func ExampleNumberFormat() {
+	de, _ := normalizedsort.NewNumberFormat("de")
+	de.Roman = true
+	ss := []string{"Band IX", "Band 2,5", "Band IV", "Band 1.000", "Band X"}
+	normalizedsort.SortFunc(ss, de.Compare)
+	fmt.Printf("%q\n", ss)
+	// Output: ["Band 2,5" "Band IV" "Band IX" "Band X" "Band 1.000"]
+}
+
+func TestNumberFormat(t *testing.T) {
+	en, _ := normalizedsort.NewNumberFormat("en_US")
+	fr, _ := normalizedsort.NewNumberFormat("fr-FR")
+	ch, _ := normalizedsort.NewNumberFormat("de-CH")
+	roman := *en
+	roman.Roman = true
+	for _, tc := range []struct {
+		f    *normalizedsort.NumberFormat
+		a, b string
+		want int
+	}{
+		{en, "1,234.50", "1234.5", 0},
+		{en, "1,000", "999", 1},
+		{en, "1,5", "1,10", -1},
+		{en, "-3", "2", -1},
+		{en, "-3", "-20", 1},
+		{en, "0", "-0.0", 0},
+		{en, "1e6", "999,999", 1},
+		{en, "1.5e-3", "0.0015", 0},
+		{en, "1e99999999999999999999", "1e300", 1},
+		{en, "1e-99999999999999999999", "1e-300", -1},
+		{en, "1e-99999999999999999999", "0", 1},
+		{en, "2024-01-05", "2024-1-5", 0},
+		{en, "2024-01-05", "2024-01-10", -1},
+		{en, "x-3", "x-2", 1},
+		{en, "12%", "9%", 1},
+		{en, "3em", "3EM", 0},
+		{en, "item 7", "Item 7", 0},
+		{en, "file", "file1", -1},
+		{en, "1 apple", "apple", -1},
+		{en, "IV", "V", -1},
+		{&roman, "IV", "V", -1},
+		{&roman, "Chapter IX", "Chapter 10", -1},
+		{&roman, "MIX", "MX", -1},
+		{&roman, "IIII", "V", 1},
+		{&roman, "Xylophone", "11", 1},
+		{fr, "1 234,5", "1234,50", 0},
+		{fr, "1 000 000", "999 999", 1},
+		{fr, "1,5", "1,25", 1},
+		{ch, "1'234.5", "1234.5", 0},
+	} {
+		if got := tc.f.Compare(tc.a, tc.b); got != tc.want {
+			t.Errorf("%s Compare(%q, %q) = %d; want %d", tc.f.Locale, tc.a, tc.b, got, tc.want)
+		}
+		if got := tc.f.Compare(tc.b, tc.a); got != -tc.want {
+			t.Errorf("%s Compare(%q, %q) = %d; want %d", tc.f.Locale, tc.b, tc.a, got, -tc.want)
+		}
+	}
+	if _, err := normalizedsort.NewNumberFormat("xx"); err == nil {
+		t.Error("NewNumberFormat(xx) succeeded")
+	}
+}
@@ -0,0 +1,102 @@
+package normalizedsort
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// parallelThreshold is the length below which SortParallel sorts
+// sequentially, since the goroutines would cost more than they save.
+const parallelThreshold = 1 << 12
+
+// SortParallel is like Sort, but normalizes and sorts ss using up to
+// runtime.GOMAXPROCS(0) goroutines. The slice is split into chunks that
+// are normalized and sorted concurrently, and the sorted chunks are then
+// merged pairwise in parallel. The result is identical to that of Sort.
+func SortParallel(ss []string, normalize func(string) string) {
+	sortParallel(ss, normalize, runtime.GOMAXPROCS(0))
+}
+
+func sortParallel(ss []string, normalize func(string) string, workers int) {
+	if normalize == nil {
+		normalize = strings.ToLower
+	}
+	if workers < 2 || len(ss) < parallelThreshold {
+		Sort(ss, normalize)
+		return
+	}
+
+	ns := normalizedStringSlice{
+		original:   ss,
+		normalized: make([]string, len(ss)),
+	}
+	// Chunk boundaries: chunk i is [bounds[i], bounds[i+1]).
+	bounds := make([]int, workers+1)
+	for i := range bounds {
+		bounds[i] = i * len(ss) / workers
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			for j := lo; j < hi; j++ {
+				ns.normalized[j] = normalize(ss[j])
+			}
+			sort.Sort(&normalizedStringSlice{
+				original:   ns.original[lo:hi],
+				normalized: ns.normalized[lo:hi],
+			})
+		}(bounds[i], bounds[i+1])
+	}
+	wg.Wait()
+
+	src := ns
+	dst := normalizedStringSlice{
+		original:   make([]string, len(ss)),
+		normalized: make([]string, len(ss)),
+	}
+	for len(bounds) > 2 {
+		next := []int{0}
+		for i := 0; i+1 < len(bounds); i += 2 {
+			lo, hi := bounds[i], bounds[i+1]
+			if i+2 >= len(bounds) {
+				// Odd run out: carry it over to the next round.
+				copy(dst.original[lo:hi], src.original[lo:hi])
+				copy(dst.normalized[lo:hi], src.normalized[lo:hi])
+				next = append(next, hi)
+				continue
+			}
+			end := bounds[i+2]
+			wg.Add(1)
+			go func(lo, mid, hi int) {
+				defer wg.Done()
+				mergeRuns(&dst, &src, lo, mid, hi)
+			}(lo, hi, end)
+			next = append(next, end)
+		}
+		wg.Wait()
+		src, dst = dst, src
+		bounds = next
+	}
+	if &src.original[0] != &ss[0] {
+		copy(ss, src.original)
+	}
+}
+
+// mergeRuns merges the sorted runs src[lo:mid] and src[mid:hi] into dst[lo:hi].
+func mergeRuns(dst, src *normalizedStringSlice, lo, mid, hi int) {
+	i, j := lo, mid
+	for k := lo; k < hi; k++ {
+		if j >= hi || i < mid && !src.Less(j, i) {
+			dst.original[k], dst.normalized[k] = src.original[i], src.normalized[i]
+			i++
+		} else {
+			dst.original[k], dst.normalized[k] = src.original[j], src.normalized[j]
+			j++
+		}
+	}
+}
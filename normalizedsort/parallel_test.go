@@ -0,0 +1,51 @@
+package normalizedsort_test
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func TestSortParallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+
+	lines := randomLines(50000)
+
+	for _, procs := range []int{1, 2, 3, 7, 16} {
+		runtime.GOMAXPROCS(procs)
+		for _, n := range []int{0, 1, 100, 5000, 50000} {
+			got := append([]string(nil), lines[:n]...)
+			normalizedsort.SortParallel(got, normalizedsort.CaseInsensitiveTrimSpace)
+			expected := append([]string(nil), lines[:n]...)
+			normalizedsort.Sort(expected, normalizedsort.CaseInsensitiveTrimSpace)
+			for i := range got {
+				if got[i] != expected[i] {
+					t.Fatalf("procs=%d n=%d: got[%d] = %q; want %q", procs, n, i, got[i], expected[i])
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkSort(b *testing.B) {
+	for _, n := range []int{1000, 100000, 1000000} {
+		lines := randomLines(n)
+		ss := make([]string, n)
+		for _, bench := range []struct {
+			name string
+			sort func([]string, func(string) string)
+		}{
+			{"sequential", normalizedsort.Sort},
+			{"parallel", normalizedsort.SortParallel},
+		} {
+			b.Run(fmt.Sprintf("%s/n=%d", bench.name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					copy(ss, lines)
+					bench.sort(ss, nil)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,823 @@
+package normalizedsort
+
+import (
+	"sort"
+	"strings"
+)
+
+// Group sorts ss as Sort does and returns it split into runs of strings
+// with the same normalized form. With a phonetic normalizer such as
+// Soundex, each group holds names that sound alike. If normalize is nil,
+// strings.ToLower is used.
+func Group(ss []string, normalize func(string) string) [][]string {
+	if normalize == nil {
+		normalize = strings.ToLower
+	}
+	ns := normalizedStringSlice{original: ss}
+	ns.init(normalize)
+	sort.Sort(&ns)
+
+	var groups [][]string
+	start := 0
+	for i := 1; i <= len(ss); i++ {
+		if i == len(ss) || ns.normalized[i] != ns.normalized[start] {
+			groups = append(groups, ss[start:i:i])
+			start = i
+		}
+	}
+	return groups
+}
+
+// asciiLetters upper-cases s and keeps only its ASCII letters.
+func asciiLetters(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if 'A' <= c && c <= 'Z' {
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// soundexCodes holds the American Soundex digit of each letter A-Z. Vowels
+// are '0' and H and W are ' ', since they separate letters differently.
+const soundexCodes = "0123012 02245501262301 202"
+
+// Soundex returns the American Soundex code of s, such as "S530" for
+// both "Smith" and "Smyth": the first letter followed by three digits
+// for the consonants that follow. Letters with the same code separated
+// only by H or W are coded once. Characters other than ASCII letters are
+// ignored; s without any letters codes as "".
+//
+// See https://www.archives.gov/research/census/soundex
+func Soundex(s string) string {
+	letters := asciiLetters(s)
+	if len(letters) == 0 {
+		return ""
+	}
+	code := []byte{letters[0]}
+	last := soundexCodes[letters[0]-'A']
+	for _, c := range letters[1:] {
+		d := soundexCodes[c-'A']
+		switch {
+		case d == ' ':
+			continue
+		case d != '0' && d != last:
+			code = append(code, d)
+		}
+		last = d
+		if len(code) == 4 {
+			break
+		}
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+// NYSIIS returns the New York State Identification and Intelligence
+// System phonetic code of s, as described by Taft (1970). The original
+// system kept only the first six characters; the full code is returned
+// here and may be truncated by the caller. Characters other than ASCII
+// letters are ignored.
+func NYSIIS(s string) string {
+	name := asciiLetters(s)
+	if len(name) == 0 {
+		return ""
+	}
+	hasPrefix := func(p string) bool { return strings.HasPrefix(string(name), p) }
+	hasSuffix := func(p string) bool { return strings.HasSuffix(string(name), p) }
+	isVowel := func(c byte) bool { return strings.IndexByte("AEIOU", c) >= 0 }
+
+	switch {
+	case hasPrefix("MAC"):
+		copy(name, "MCC")
+	case hasPrefix("KN"):
+		copy(name, "NN")
+	case hasPrefix("K"):
+		name[0] = 'C'
+	case hasPrefix("PH"), hasPrefix("PF"):
+		copy(name, "FF")
+	case hasPrefix("SCH"):
+		copy(name, "SSS")
+	}
+	switch {
+	case hasSuffix("EE"), hasSuffix("IE"):
+		name = append(name[:len(name)-2], 'Y')
+	case hasSuffix("DT"), hasSuffix("RT"), hasSuffix("RD"),
+		hasSuffix("NT"), hasSuffix("ND"):
+		name = append(name[:len(name)-2], 'D')
+	}
+
+	key := []byte{name[0]}
+	at := func(i int) byte {
+		if i < len(name) {
+			return name[i]
+		}
+		return 0
+	}
+	for i := 1; i < len(name); i++ {
+		switch c := name[i]; {
+		case c == 'E' && at(i+1) == 'V':
+			name[i], name[i+1] = 'A', 'F'
+		case isVowel(c):
+			name[i] = 'A'
+		case c == 'Q':
+			name[i] = 'G'
+		case c == 'Z':
+			name[i] = 'S'
+		case c == 'M':
+			name[i] = 'N'
+		case c == 'K':
+			if at(i+1) == 'N' {
+				name[i] = 'N'
+			} else {
+				name[i] = 'C'
+			}
+		case c == 'S' && at(i+1) == 'C' && at(i+2) == 'H':
+			name[i+1], name[i+2] = 'S', 'S'
+		case c == 'P' && at(i+1) == 'H':
+			name[i], name[i+1] = 'F', 'F'
+		case c == 'H' && (!isVowel(name[i-1]) || i+1 == len(name) || !isVowel(name[i+1])):
+			name[i] = name[i-1]
+		case c == 'W' && isVowel(name[i-1]):
+			name[i] = name[i-1]
+		}
+		if name[i] != key[len(key)-1] {
+			key = append(key, name[i])
+		}
+	}
+
+	if len(key) > 1 && key[len(key)-1] == 'S' {
+		key = key[:len(key)-1]
+	}
+	if len(key) > 2 && string(key[len(key)-2:]) == "AY" {
+		key = append(key[:len(key)-2], 'Y')
+	}
+	if len(key) > 1 && key[len(key)-1] == 'A' {
+		key = key[:len(key)-1]
+	}
+	return string(key)
+}
+
+// DoubleMetaphoneKey returns the primary Double Metaphone code of s,
+// for use as a normalization function.
+func DoubleMetaphoneKey(s string) string {
+	primary, _ := DoubleMetaphone(s)
+	return primary
+}
+
+// DoubleMetaphone returns the primary and alternate codes of s according
+// to Lawrence Philips' Double Metaphone algorithm, which accounts for the
+// spelling of names from many languages. Codes are at most four
+// characters long, and "0" stands for "th". If s has only one plausible
+// pronunciation, both codes are the same.
+//
+// This is a port of the original C++ implementation published with
+// "The Double Metaphone Search Algorithm", C/C++ Users Journal, June 2000.
+func DoubleMetaphone(s string) (primary, alternate string) {
+	m := metaphone{word: []rune(strings.ToUpper(s))}
+	m.encode()
+	primary, alternate = m.primary.String(), m.secondary.String()
+	if len(primary) > 4 {
+		primary = primary[:4]
+	}
+	if len(alternate) > 4 {
+		alternate = alternate[:4]
+	}
+	return primary, alternate
+}
+
+type metaphone struct {
+	word               []rune
+	primary, secondary strings.Builder
+}
+
+// at returns the rune at i, or a space past the end of the word, as the
+// original pads its input with spaces.
+func (m *metaphone) at(i int) rune {
+	if i < 0 || i >= len(m.word) {
+		return ' '
+	}
+	return m.word[i]
+}
+
+// stringAt reports whether any of options occurs at start.
+func (m *metaphone) stringAt(start int, options ...string) bool {
+	if start < 0 {
+		return false
+	}
+	for _, opt := range options {
+		match := true
+		for i, r := range []rune(opt) {
+			if m.at(start+i) != r {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *metaphone) isVowel(i int) bool {
+	if i < 0 || i >= len(m.word) {
+		return false
+	}
+	return strings.ContainsRune("AEIOUY", m.word[i])
+}
+
+func (m *metaphone) slavoGermanic() bool {
+	w := string(m.word)
+	return strings.ContainsAny(w, "WK") || strings.Contains(w, "CZ") || strings.Contains(w, "WITZ")
+}
+
+func (m *metaphone) add(main string) {
+	m.primary.WriteString(main)
+	m.secondary.WriteString(main)
+}
+
+// add2 adds main to the primary code and alt to the alternate code.
+// An alt of " " adds nothing to the alternate code.
+func (m *metaphone) add2(main, alt string) {
+	m.primary.WriteString(main)
+	if alt != " " {
+		m.secondary.WriteString(alt)
+	}
+}
+
+func (m *metaphone) germanic() bool {
+	return m.stringAt(0, "VAN ", "VON ", "SCH")
+}
+
+func (m *metaphone) encode() {
+	length := len(m.word)
+	last := length - 1
+	cur := 0
+
+	// Skip these when at the start of the word.
+	if m.stringAt(0, "GN", "KN", "PN", "WR", "PS") {
+		cur++
+	}
+	// Initial 'X' is pronounced 'Z', e.g. "Xavier".
+	if m.at(0) == 'X' {
+		m.add("S")
+		cur++
+	}
+
+	for m.primary.Len() < 4 || m.secondary.Len() < 4 {
+		if cur >= length {
+			break
+		}
+		switch m.word[cur] {
+		case 'A', 'E', 'I', 'O', 'U', 'Y':
+			if cur == 0 {
+				// All initial vowels map to 'A'.
+				m.add("A")
+			}
+			cur++
+
+		case 'B':
+			// "-mb", e.g. "dumb", is handled under 'M'.
+			m.add("P")
+			if m.at(cur+1) == 'B' {
+				cur += 2
+			} else {
+				cur++
+			}
+
+		case 'Ç':
+			m.add("S")
+			cur++
+
+		case 'C':
+			cur = m.encodeC(cur)
+
+		case 'D':
+			switch {
+			case m.stringAt(cur, "DG"):
+				if m.stringAt(cur+2, "I", "E", "Y") {
+					// e.g. "edge"
+					m.add("J")
+					cur += 3
+				} else {
+					// e.g. "edgar"
+					m.add("TK")
+					cur += 2
+				}
+			case m.stringAt(cur, "DT", "DD"):
+				m.add("T")
+				cur += 2
+			default:
+				m.add("T")
+				cur++
+			}
+
+		case 'F':
+			if m.at(cur+1) == 'F' {
+				cur += 2
+			} else {
+				cur++
+			}
+			m.add("F")
+
+		case 'G':
+			cur = m.encodeG(cur)
+
+		case 'H':
+			// Only keep if first and before a vowel, or between two vowels.
+			if (cur == 0 || m.isVowel(cur-1)) && m.isVowel(cur+1) {
+				m.add("H")
+				cur += 2
+			} else {
+				// Also takes care of "HH".
+				cur++
+			}
+
+		case 'J':
+			cur = m.encodeJ(cur, last)
+
+		case 'K':
+			if m.at(cur+1) == 'K' {
+				cur += 2
+			} else {
+				cur++
+			}
+			m.add("K")
+
+		case 'L':
+			if m.at(cur+1) == 'L' {
+				// Spanish, e.g. "cabrillo", "gallegos".
+				if cur == length-3 && m.stringAt(cur-1, "ILLO", "ILLA", "ALLE") ||
+					(m.stringAt(last-1, "AS", "OS") || m.stringAt(last, "A", "O")) &&
+						m.stringAt(cur-1, "ALLE") {
+					m.add2("L", " ")
+					cur += 2
+					break
+				}
+				cur += 2
+			} else {
+				cur++
+			}
+			m.add("L")
+
+		case 'M':
+			if m.stringAt(cur-1, "UMB") && (cur+1 == last || m.stringAt(cur+2, "ER")) ||
+				m.at(cur+1) == 'M' {
+				cur += 2
+			} else {
+				cur++
+			}
+			m.add("M")
+
+		case 'N':
+			if m.at(cur+1) == 'N' {
+				cur += 2
+			} else {
+				cur++
+			}
+			m.add("N")
+
+		case 'Ñ':
+			cur++
+			m.add("N")
+
+		case 'P':
+			if m.at(cur+1) == 'H' {
+				m.add("F")
+				cur += 2
+				break
+			}
+			// Also account for "campbell" and "raspberry".
+			if m.stringAt(cur+1, "P", "B") {
+				cur += 2
+			} else {
+				cur++
+			}
+			m.add("P")
+
+		case 'Q':
+			if m.at(cur+1) == 'Q' {
+				cur += 2
+			} else {
+				cur++
+			}
+			m.add("K")
+
+		case 'R':
+			// French, e.g. "rogier", but exclude "hochmeier".
+			if cur == last && !m.slavoGermanic() && m.stringAt(cur-2, "IE") &&
+				!m.stringAt(cur-4, "ME", "MA") {
+				m.add2("", "R")
+			} else {
+				m.add("R")
+			}
+			if m.at(cur+1) == 'R' {
+				cur += 2
+			} else {
+				cur++
+			}
+
+		case 'S':
+			cur = m.encodeS(cur, last)
+
+		case 'T':
+			switch {
+			case m.stringAt(cur, "TION"):
+				m.add("X")
+				cur += 3
+			case m.stringAt(cur, "TIA", "TCH"):
+				m.add("X")
+				cur += 3
+			case m.stringAt(cur, "TH", "TTH"):
+				// Special case "thomas", "thames", or Germanic.
+				if m.stringAt(cur+2, "OM", "AM") || m.germanic() {
+					m.add("T")
+				} else {
+					m.add2("0", "T")
+				}
+				cur += 2
+			default:
+				if m.stringAt(cur+1, "T", "D") {
+					cur += 2
+				} else {
+					cur++
+				}
+				m.add("T")
+			}
+
+		case 'V':
+			if m.at(cur+1) == 'V' {
+				cur += 2
+			} else {
+				cur++
+			}
+			m.add("F")
+
+		case 'W':
+			cur = m.encodeW(cur, last)
+
+		case 'X':
+			// French, e.g. "breaux".
+			if !(cur == last && (m.stringAt(cur-3, "IAU", "EAU") || m.stringAt(cur-2, "AU", "OU"))) {
+				m.add("KS")
+			}
+			if m.stringAt(cur+1, "C", "X") {
+				cur += 2
+			} else {
+				cur++
+			}
+
+		case 'Z':
+			// Chinese pinyin, e.g. "zhao".
+			if m.at(cur+1) == 'H' {
+				m.add("J")
+				cur += 2
+				break
+			}
+			if m.stringAt(cur+1, "ZO", "ZI", "ZA") ||
+				m.slavoGermanic() && cur > 0 && m.at(cur-1) != 'T' {
+				m.add2("S", "TS")
+			} else {
+				m.add("S")
+			}
+			if m.at(cur+1) == 'Z' {
+				cur += 2
+			} else {
+				cur++
+			}
+
+		default:
+			cur++
+		}
+	}
+}
+
+func (m *metaphone) encodeC(cur int) int {
+	// Various Germanic.
+	if cur > 1 && !m.isVowel(cur-2) && m.stringAt(cur-1, "ACH") &&
+		m.at(cur+2) != 'I' && (m.at(cur+2) != 'E' || m.stringAt(cur-2, "BACHER", "MACHER")) {
+		m.add("K")
+		return cur + 2
+	}
+	// Special case "caesar".
+	if cur == 0 && m.stringAt(cur, "CAESAR") {
+		m.add("S")
+		return cur + 2
+	}
+	// Italian "chianti".
+	if m.stringAt(cur, "CHIA") {
+		m.add("K")
+		return cur + 2
+	}
+	if m.stringAt(cur, "CH") {
+		// Find "michael".
+		if cur > 0 && m.stringAt(cur, "CHAE") {
+			m.add2("K", "X")
+			return cur + 2
+		}
+		// Greek roots, e.g. "chemistry", "chorus".
+		if cur == 0 && (m.stringAt(cur+1, "HARAC", "HARIS") || m.stringAt(cur+1, "HOR", "HYM", "HIA", "HEM")) &&
+			!m.stringAt(0, "CHORE") {
+			m.add("K")
+			return cur + 2
+		}
+		// Germanic, Greek, or otherwise "ch" for the "kh" sound.
+		if m.germanic() ||
+			// "architect" but not "arch", "orchestra", "orchid".
+			m.stringAt(cur-2, "ORCHES", "ARCHIT", "ORCHID") ||
+			m.stringAt(cur+2, "T", "S") ||
+			// e.g. "wachtler", "wechsler", but not "tichner".
+			(m.stringAt(cur-1, "A", "O", "U", "E") || cur == 0) &&
+				m.stringAt(cur+2, "L", "R", "N", "M", "B", "H", "F", "V", "W", " ") {
+			m.add("K")
+		} else if cur > 0 {
+			if m.stringAt(0, "MC") {
+				// e.g. "McHugh"
+				m.add("K")
+			} else {
+				m.add2("X", "K")
+			}
+		} else {
+			m.add("X")
+		}
+		return cur + 2
+	}
+	// e.g. "czerny"
+	if m.stringAt(cur, "CZ") && !m.stringAt(cur-2, "WICZ") {
+		m.add2("S", "X")
+		return cur + 2
+	}
+	// e.g. "focaccia"
+	if m.stringAt(cur+1, "CIA") {
+		m.add("X")
+		return cur + 3
+	}
+	// Double 'C', but not if e.g. "McClellan".
+	if m.stringAt(cur, "CC") && !(cur == 1 && m.at(0) == 'M') {
+		// "bellocchio" but not "bacchus"
+		if m.stringAt(cur+2, "I", "E", "H") && !m.stringAt(cur+2, "HU") {
+			if cur == 1 && m.at(cur-1) == 'A' || m.stringAt(cur-1, "UCCEE", "UCCES") {
+				// "accident", "accede", "succeed"
+				m.add("KS")
+			} else {
+				// "bacci", "bertucci", other Italian
+				m.add("X")
+			}
+			return cur + 3
+		}
+		// Pierce's rule
+		m.add("K")
+		return cur + 2
+	}
+	if m.stringAt(cur, "CK", "CG", "CQ") {
+		m.add("K")
+		return cur + 2
+	}
+	if m.stringAt(cur, "CI", "CE", "CY") {
+		// Italian vs. English
+		if m.stringAt(cur, "CIO", "CIE", "CIA") {
+			m.add2("S", "X")
+		} else {
+			m.add("S")
+		}
+		return cur + 2
+	}
+	m.add("K")
+	// Names such as "mac caffrey", "mac gregor".
+	switch {
+	case m.stringAt(cur+1, " C", " Q", " G"):
+		return cur + 3
+	case m.stringAt(cur+1, "C", "K", "Q") && !m.stringAt(cur+1, "CE", "CI"):
+		return cur + 2
+	}
+	return cur + 1
+}
+
+func (m *metaphone) encodeG(cur int) int {
+	if m.at(cur+1) == 'H' {
+		if cur > 0 && !m.isVowel(cur-1) {
+			m.add("K")
+			return cur + 2
+		}
+		// "ghislane", "ghiradelli"
+		if cur == 0 {
+			if m.at(cur+2) == 'I' {
+				m.add("J")
+			} else {
+				m.add("K")
+			}
+			return cur + 2
+		}
+		// Parker's rule (with some further refinements), e.g. "hugh",
+		// "bough", "broughton".
+		if cur > 1 && m.stringAt(cur-2, "B", "H", "D") ||
+			cur > 2 && m.stringAt(cur-3, "B", "H", "D") ||
+			cur > 3 && m.stringAt(cur-4, "B", "H") {
+			return cur + 2
+		}
+		// e.g. "laugh", "McLaughlin", "cough", "gough", "rough", "tough"
+		if cur > 2 && m.at(cur-1) == 'U' && m.stringAt(cur-3, "C", "G", "L", "R", "T") {
+			m.add("F")
+		} else if cur > 0 && m.at(cur-1) != 'I' {
+			m.add("K")
+		}
+		return cur + 2
+	}
+
+	if m.at(cur+1) == 'N' {
+		if cur == 1 && m.isVowel(0) && !m.slavoGermanic() {
+			m.add2("KN", "N")
+		} else if !m.stringAt(cur+2, "EY") && m.at(cur+1) != 'Y' && !m.slavoGermanic() {
+			// Not e.g. "cagney".
+			m.add2("N", "KN")
+		} else {
+			m.add("KN")
+		}
+		return cur + 2
+	}
+
+	// "tagliaro"
+	if m.stringAt(cur+1, "LI") && !m.slavoGermanic() {
+		m.add2("KL", "L")
+		return cur + 2
+	}
+
+	// -ges-, -gep-, -gel-, -gie- at the beginning.
+	if cur == 0 && (m.at(cur+1) == 'Y' ||
+		m.stringAt(cur+1, "ES", "EP", "EB", "EL", "EY", "IB", "IL", "IN", "IE", "EI", "ER")) {
+		m.add2("K", "J")
+		return cur + 2
+	}
+
+	// -ger-, -gy-
+	if (m.stringAt(cur+1, "ER") || m.at(cur+1) == 'Y') &&
+		!m.stringAt(0, "DANGER", "RANGER", "MANGER") &&
+		!m.stringAt(cur-1, "E", "I") && !m.stringAt(cur-1, "RGY", "OGY") {
+		m.add2("K", "J")
+		return cur + 2
+	}
+
+	// Italian, e.g. "biaggi"
+	if m.stringAt(cur+1, "E", "I", "Y") || m.stringAt(cur-1, "AGGI", "OGGI") {
+		if m.germanic() || m.stringAt(cur+1, "ET") {
+			// Obviously Germanic.
+			m.add("K")
+		} else if m.stringAt(cur+1, "IER ") {
+			// Always soft if French ending.
+			m.add("J")
+		} else {
+			m.add2("J", "K")
+		}
+		return cur + 2
+	}
+
+	m.add("K")
+	if m.at(cur+1) == 'G' {
+		return cur + 2
+	}
+	return cur + 1
+}
+
+func (m *metaphone) encodeJ(cur, last int) int {
+	// Obvious Spanish, "jose", "san jacinto".
+	if m.stringAt(cur, "JOSE") || m.stringAt(0, "SAN ") {
+		if cur == 0 && m.at(cur+4) == ' ' || m.stringAt(0, "SAN ") {
+			m.add("H")
+		} else {
+			m.add2("J", "H")
+		}
+		return cur + 1
+	}
+
+	if cur == 0 && !m.stringAt(cur, "JOSE") {
+		// Yankelovich/Jankelowicz
+		m.add2("J", "A")
+	} else if m.isVowel(cur-1) && !m.slavoGermanic() && (m.at(cur+1) == 'A' || m.at(cur+1) == 'O') {
+		// Spanish pronunciation of e.g. "bajador".
+		m.add2("J", "H")
+	} else if cur == last {
+		m.add2("J", " ")
+	} else if !m.stringAt(cur+1, "L", "T", "K", "S", "N", "M", "B", "Z") &&
+		!m.stringAt(cur-1, "S", "K", "L") {
+		m.add("J")
+	}
+
+	if m.at(cur+1) == 'J' {
+		return cur + 2
+	}
+	return cur + 1
+}
+
+func (m *metaphone) encodeS(cur, last int) int {
+	// Special cases "island", "isle", "carlisle", "carlysle".
+	if m.stringAt(cur-1, "ISL", "YSL") {
+		return cur + 1
+	}
+	// Special case "sugar-".
+	if cur == 0 && m.stringAt(cur, "SUGAR") {
+		m.add2("X", "S")
+		return cur + 1
+	}
+	if m.stringAt(cur, "SH") {
+		// Germanic
+		if m.stringAt(cur+1, "HEIM", "HOEK", "HOLM", "HOLZ") {
+			m.add("S")
+		} else {
+			m.add("X")
+		}
+		return cur + 2
+	}
+	// Italian and Armenian
+	if m.stringAt(cur, "SIO", "SIA") || m.stringAt(cur, "SIAN") {
+		if !m.slavoGermanic() {
+			m.add2("S", "X")
+		} else {
+			m.add("S")
+		}
+		return cur + 3
+	}
+	// German and anglicisations, e.g. "smith" matches "schmidt", "snider"
+	// matches "schneider". Also -sz- in Slavic languages, although in
+	// Hungarian it is pronounced 's'.
+	if cur == 0 && m.stringAt(cur+1, "M", "N", "L", "W") || m.stringAt(cur+1, "Z") {
+		m.add2("S", "X")
+		if m.stringAt(cur+1, "Z") {
+			return cur + 2
+		}
+		return cur + 1
+	}
+	if m.stringAt(cur, "SC") {
+		// Schlesinger's rule
+		if m.at(cur+2) == 'H' {
+			// Dutch origin, e.g. "school", "schooner".
+			if m.stringAt(cur+3, "OO", "ER", "EN", "UY", "ED", "EM") {
+				// "schermerhorn", "schenker"
+				if m.stringAt(cur+3, "ER", "EN") {
+					m.add2("X", "SK")
+				} else {
+					m.add("SK")
+				}
+				return cur + 3
+			}
+			if cur == 0 && !m.isVowel(3) && m.at(3) != 'W' {
+				m.add2("X", "S")
+			} else {
+				m.add("X")
+			}
+			return cur + 3
+		}
+		if m.stringAt(cur+2, "I", "E", "Y") {
+			m.add("S")
+			return cur + 3
+		}
+		m.add("SK")
+		return cur + 3
+	}
+	// French, e.g. "resnais", "artois".
+	if cur == last && m.stringAt(cur-2, "AI", "OI") {
+		m.add2("", "S")
+	} else {
+		m.add("S")
+	}
+	if m.stringAt(cur+1, "S", "Z") {
+		return cur + 2
+	}
+	return cur + 1
+}
+
+func (m *metaphone) encodeW(cur, last int) int {
+	// Can also be in the middle of a word.
+	if m.stringAt(cur, "WR") {
+		m.add("R")
+		return cur + 2
+	}
+	if cur == 0 && (m.isVowel(cur+1) || m.stringAt(cur, "WH")) {
+		if m.isVowel(cur + 1) {
+			// Wasserman should match Vasserman.
+			m.add2("A", "F")
+		} else {
+			// Need Uomo to match Womo.
+			m.add("A")
+		}
+	}
+	// Arnow should match Arnoff.
+	if cur == last && m.isVowel(cur-1) ||
+		m.stringAt(cur-1, "EWSKI", "EWSKY", "OWSKI", "OWSKY") || m.stringAt(0, "SCH") {
+		m.add2("", "F")
+		return cur + 1
+	}
+	// Polish, e.g. "filipowicz".
+	if m.stringAt(cur, "WICZ", "WITZ") {
+		m.add2("TS", "FX")
+		return cur + 4
+	}
+	return cur + 1
+}
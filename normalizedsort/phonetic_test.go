@@ -0,0 +1,83 @@
+package normalizedsort_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func ExampleGroup() {
+	names := []string{"Smith", "Catherine", "Smyth", "Kathryn", "Jones", "Schmidt"}
+	for _, g := range normalizedsort.Group(names, normalizedsort.DoubleMetaphoneKey) {
+		fmt.Printf("%q\n", g)
+	}
+	// Output:
+	// ["Jones"]
+	// ["Catherine" "Kathryn"]
+	// ["Smith" "Smyth"]
+	// ["Schmidt"]
+}
+
+func TestSoundex(t *testing.T) {
+	// Examples from the National Archives' description of the system.
+	for in, want := range map[string]string{
+		"Washington": "W252", "Lee": "L000", "Gutierrez": "G362",
+		"Pfister": "P236", "Jackson": "J250", "Tymczak": "T522",
+		"VanDeusen": "V532", "Ashcraft": "A261", "Rubin": "R150",
+		"Rupert": "R163", "Robert": "R163", "Honeyman": "H555",
+		"Smith": "S530", "Smyth": "S530", "": "", "O'Hara": "O600",
+	} {
+		if got := normalizedsort.Soundex(in); got != want {
+			t.Errorf("Soundex(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
+
+func TestNYSIIS(t *testing.T) {
+	for in, want := range map[string]string{
+		"Worthy": "WARTY", "Ogata": "OGAT", "Montgomery": "MANTGANARY",
+		"Mitchell": "MATCAL", "Knight": "NAGT", "Brian": "BRAN",
+		"Bishop": "BASAP", "Catherine": "CATARAN", "Kathryn": "CATRYN",
+		"MacIntosh": "MCANT", "Schmidt": "SNAD", "Phillips": "FALAP",
+		"Dickens": "DACAN", "Smith": "SNAT", "Smyth": "SNYT", "": "",
+	} {
+		if got := normalizedsort.NYSIIS(in); got != want {
+			t.Errorf("NYSIIS(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
+
+func TestDoubleMetaphone(t *testing.T) {
+	// Examples from Philips' article and the comments of his implementation.
+	for _, tc := range []struct{ in, primary, alternate string }{
+		{"Smith", "SM0", "XMT"},
+		{"Schmidt", "XMT", "SMT"},
+		{"Jose", "HS", "HS"},
+		{"Xavier", "SF", "SFR"},
+		{"Knight", "NT", "NT"},
+		{"Catherine", "K0RN", "KTRN"},
+		{"Kathryn", "K0RN", "KTRN"},
+		{"Caesar", "SSR", "SSR"},
+		{"Arnow", "ARN", "ARNF"},
+		{"Arnoff", "ARNF", "ARNF"},
+		{"Wasserman", "ASRM", "FSRM"},
+		{"Michael", "MKL", "MXL"},
+		{"Edge", "AJ", "AJ"},
+		{"Thomas", "TMS", "TMS"},
+		{"Filipowicz", "FLPT", "FLPF"},
+		{"Czerny", "SRN", "XRN"},
+		{"Focaccia", "FKX", "FKX"},
+		{"Accident", "AKST", "AKST"},
+		{"Gallegos", "KLKS", "KKS"},
+		{"Laugh", "LF", "LF"},
+		{"Hugh", "H", "H"},
+		{"Zhao", "J", "J"},
+		{"", "", ""},
+	} {
+		p, a := normalizedsort.DoubleMetaphone(tc.in)
+		if p != tc.primary || a != tc.alternate {
+			t.Errorf("DoubleMetaphone(%q) = %q, %q; want %q, %q", tc.in, p, a, tc.primary, tc.alternate)
+		}
+	}
+}
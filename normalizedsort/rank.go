@@ -0,0 +1,327 @@
+package normalizedsort
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A MatchKind is how a string matched a query in Rank, from worst to best.
+type MatchKind int
+
+const (
+	// NoMatch means the string did not match the query.
+	NoMatch MatchKind = iota
+	// Fuzzy means a part of the string is within a small edit distance
+	// of the query: one edit for queries of four or more characters, and
+	// one more for every four characters after that.
+	Fuzzy
+	// Subsequence means the characters of the query appear in the string
+	// in order, but not together.
+	Subsequence
+	// Substring means the query appears inside a word of the string.
+	Substring
+	// WordPrefix means a word of the string other than the first starts
+	// with the query. Words start after a character that is not a letter
+	// or digit, or at an upper-case letter following a lower-case one.
+	WordPrefix
+	// Prefix means the string starts with the query.
+	Prefix
+)
+
+func (k MatchKind) String() string {
+	switch k {
+	case NoMatch:
+		return "no match"
+	case Fuzzy:
+		return "fuzzy"
+	case Subsequence:
+		return "subsequence"
+	case Substring:
+		return "substring"
+	case WordPrefix:
+		return "word prefix"
+	case Prefix:
+		return "prefix"
+	}
+	return fmt.Sprintf("MatchKind(%d)", int(k))
+}
+
+// A Match is a string of the slice passed to Rank that matched the query.
+type Match struct {
+	// Index is the position of Value in the slice.
+	Index int
+	Value string
+	Kind  MatchKind
+	// Score ranks the Match: a better Kind always scores higher, and
+	// within a Kind, tighter matches in shorter strings score higher.
+	Score int
+	// Positions holds the byte offsets in Value of the characters that
+	// matched the query, in increasing order.
+	Positions []int
+}
+
+// Highlight returns m.Value with each run of matched characters
+// surrounded by open and close, e.g. "<b>" and "</b>".
+func (m Match) Highlight(open, close string) string {
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(m.Positions); {
+		start := m.Positions[i]
+		end := start
+		for i < len(m.Positions) && m.Positions[i] == end {
+			_, size := utf8.DecodeRuneInString(m.Value[end:])
+			end += size
+			i++
+		}
+		b.WriteString(m.Value[last:start])
+		b.WriteString(open)
+		b.WriteString(m.Value[start:end])
+		b.WriteString(close)
+		last = end
+	}
+	b.WriteString(m.Value[last:])
+	return b.String()
+}
+
+// Rank returns the strings of ss that match query, best first. The query
+// and strings are compared after normalization, so with the default of
+// strings.ToLower matching is case-insensitive. Matches with equal scores
+// are in the order of New(ss, normalize). ss is not modified.
+//
+// To report positions in the original strings, Rank normalizes them one
+// character at a time. Normalizers that depend on context, such as
+// Natural or Name, are therefore applied to each character alone.
+func Rank(ss []string, query string, normalize func(string) string) []Match {
+	if normalize == nil {
+		normalize = strings.ToLower
+	}
+	q := newRankText(query, normalize).runes
+	var ms []Match
+	var keys []string
+	for i, s := range ss {
+		m := Match{Index: i, Value: s}
+		if len(q) > 0 {
+			m.match(q, newRankText(s, normalize))
+			if m.Kind == NoMatch {
+				continue
+			}
+		}
+		ms = append(ms, m)
+		keys = append(keys, normalize(s))
+	}
+	sort.Sort(&rankedMatches{ms, keys})
+	return ms
+}
+
+type rankedMatches struct {
+	ms   []Match
+	keys []string
+}
+
+func (r *rankedMatches) Len() int {
+	return len(r.ms)
+}
+
+func (r *rankedMatches) Less(i, j int) bool {
+	a, b := &r.ms[i], &r.ms[j]
+	switch {
+	case a.Score != b.Score:
+		return a.Score > b.Score
+	case r.keys[i] != r.keys[j]:
+		return r.keys[i] < r.keys[j]
+	}
+	return a.Index < b.Index
+}
+
+func (r *rankedMatches) Swap(i, j int) {
+	r.ms[i], r.ms[j] = r.ms[j], r.ms[i]
+	r.keys[i], r.keys[j] = r.keys[j], r.keys[i]
+}
+
+// rankText is a string normalized one character at a time. Each rune of
+// the normalized form records the offset of the original character it
+// came from and whether that character starts a word.
+type rankText struct {
+	runes     []rune
+	offsets   []int
+	wordStart []bool
+}
+
+func newRankText(s string, normalize func(string) string) rankText {
+	var t rankText
+	prev := rune(-1)
+	for off, r := range s {
+		start := !isWordRune(prev) && isWordRune(r) ||
+			unicode.IsLower(prev) && unicode.IsUpper(r)
+		for _, n := range normalize(string(r)) {
+			t.runes = append(t.runes, n)
+			t.offsets = append(t.offsets, off)
+			t.wordStart = append(t.wordStart, start)
+			start = false
+		}
+		prev = r
+	}
+	return t
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Scores are a base for each MatchKind less a penalty below tierScore.
+const tierScore = 1000
+
+func (m *Match) match(q []rune, t rankText) {
+	c := t.runes
+	// Unmatched characters make a small penalty, so shorter strings win.
+	slack := len(c) - len(q)
+	if i := indexRunes(c, q, t.wordStart); i >= 0 {
+		switch {
+		case i == 0:
+			m.Kind = Prefix
+		case t.wordStart[i]:
+			m.Kind = WordPrefix
+		default:
+			m.Kind = Substring
+			slack += i
+		}
+		m.setPositions(t, i, i+len(q))
+	} else if pos, gaps := subsequence(c, q); pos != nil {
+		m.Kind = Subsequence
+		slack += 10 * gaps
+		m.Positions = uniqueOffsets(t.offsets, pos)
+	} else if start, end, edits := fuzzy(c, q); edits > 0 {
+		m.Kind = Fuzzy
+		slack += 100 * edits
+		m.setPositions(t, start, end)
+	} else {
+		return
+	}
+	if slack >= tierScore {
+		slack = tierScore - 1
+	}
+	m.Score = int(m.Kind)*tierScore - slack
+}
+
+func (m *Match) setPositions(t rankText, start, end int) {
+	pos := make([]int, 0, end-start)
+	for i := start; i < end; i++ {
+		pos = append(pos, i)
+	}
+	m.Positions = uniqueOffsets(t.offsets, pos)
+}
+
+// uniqueOffsets maps indexes of normalized runes to original offsets,
+// dropping duplicates from characters that normalize to several runes.
+func uniqueOffsets(offsets, pos []int) []int {
+	out := make([]int, 0, len(pos))
+	for _, i := range pos {
+		if n := len(out); n == 0 || out[n-1] != offsets[i] {
+			out = append(out, offsets[i])
+		}
+	}
+	return out
+}
+
+// indexRunes returns the index of q in c, preferring an occurrence at the
+// start of c or of a word, or -1 if there is none.
+func indexRunes(c, q []rune, wordStart []bool) int {
+	first := -1
+	for i := 0; i+len(q) <= len(c); i++ {
+		if !hasPrefix(c[i:], q) {
+			continue
+		}
+		if i == 0 || wordStart[i] {
+			return i
+		}
+		if first < 0 {
+			first = i
+		}
+	}
+	return first
+}
+
+func hasPrefix(c, q []rune) bool {
+	for i := range q {
+		if c[i] != q[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// subsequence returns the indexes in c of the leftmost occurrence of q as
+// a subsequence, and the number of gaps between them, or nil.
+func subsequence(c, q []rune) (pos []int, gaps int) {
+	pos = make([]int, 0, len(q))
+	for i := 0; i < len(c) && len(pos) < len(q); i++ {
+		if c[i] != q[len(pos)] {
+			continue
+		}
+		if n := len(pos); n > 0 && pos[n-1] != i-1 {
+			gaps++
+		}
+		pos = append(pos, i)
+	}
+	if len(pos) < len(q) {
+		return nil, 0
+	}
+	return pos, gaps
+}
+
+// fuzzy finds the part c[start:end] of c with the least edit distance to
+// q, using Sellers' algorithm. It returns 0 edits if the distance exceeds
+// the limit for the length of q.
+func fuzzy(c, q []rune) (start, end, edits int) {
+	limit := len(q) / 4
+	if limit == 0 {
+		return 0, 0, 0
+	}
+	// dist[j] and from[j] hold the distance and start of the best match
+	// of the current prefix of q ending at c[j].
+	dist := make([]int, len(c)+1)
+	from := make([]int, len(c)+1)
+	for j := range from {
+		from[j] = j
+	}
+	for i := 1; i <= len(q); i++ {
+		diag, diagFrom := dist[0], from[0]
+		dist[0] = i
+		for j := 1; j <= len(c); j++ {
+			cost := 1
+			if q[i-1] == c[j-1] {
+				cost = 0
+			}
+			d, f := diag+cost, diagFrom
+			if dist[j]+1 < d {
+				d, f = dist[j]+1, from[j]
+			}
+			if dist[j-1]+1 < d {
+				d, f = dist[j-1]+1, from[j-1]
+			}
+			diag, diagFrom = dist[j], from[j]
+			dist[j], from[j] = d, f
+		}
+	}
+	// Among the closest parts, prefer the one nearest in length to q.
+	edits = limit + 1
+	for j := 1; j <= len(c); j++ {
+		if dist[j] < edits || dist[j] == edits && abs(j-from[j]-len(q)) < abs(end-start-len(q)) {
+			start, end, edits = from[j], j, dist[j]
+		}
+	}
+	if edits > limit || start == end {
+		return 0, 0, 0
+	}
+	return start, end, edits
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,55 @@
+package normalizedsort_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func ExampleRank() {
+	files := []string{"README.md", "sort.go", "external_sort.go", "Résumé.txt", "parallel.go", "sorting-notes.txt"}
+	fold := func(s string) string {
+		return strings.ToLower(normalizedsort.FoldDiacritics(s))
+	}
+	for _, query := range []string{"sort", "resume", "paralell", "rdm"} {
+		for _, m := range normalizedsort.Rank(files, query, fold) {
+			fmt.Println(m.Kind, m.Highlight("[", "]"))
+		}
+	}
+	// Output:
+	// prefix [sort].go
+	// prefix [sort]ing-notes.txt
+	// word prefix external_[sort].go
+	// prefix [Résumé].txt
+	// fuzzy [parallel].go
+	// subsequence [R]EA[DM]E.md
+}
+
+func TestRank(t *testing.T) {
+	fold := func(s string) string {
+		return strings.ToLower(normalizedsort.FoldDiacritics(s))
+	}
+	ss := []string{"Straße", "MapReduce", "reduce", "Preludes", "ridiculous", "abc"}
+	for _, tc := range []struct {
+		query string
+		want  string
+	}{
+		{"strasse", `[{0 Straße prefix 5000 [0 1 2 3 4 6]}]`},
+		{"red", `[{2 reduce prefix 4997 [0 1 2]} {1 MapReduce word prefix 3994 [3 4 5]} {3 Preludes subsequence 1985 [1 2 5]}]`},
+		{"duce", `[{2 reduce substring 2996 [2 3 4 5]} {1 MapReduce substring 2990 [5 6 7 8]}]`},
+		{"rdcs", `[{4 ridiculous subsequence 1964 [0 2 4 9]}]`},
+		{"prelude", `[{3 Preludes prefix 4999 [0 1 2 3 4 5 6]}]`},
+		{"prelued", `[{3 Preludes fuzzy 899 [0 1 2 3 4 5]}]`},
+		{"xyz", `[]`},
+	} {
+		ms := normalizedsort.Rank(ss, tc.query, fold)
+		if got := fmt.Sprint(ms); got != tc.want {
+			t.Errorf("Rank(%q) = %s; want %s", tc.query, got, tc.want)
+		}
+	}
+	if ms := normalizedsort.Rank(ss, "", nil); len(ms) != len(ss) || ms[0].Value != "abc" {
+		t.Errorf("Rank with empty query = %v", ms)
+	}
+}
@@ -0,0 +1,307 @@
+package normalizedsort
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A ColumnType selects how a Column compares its field.
+type ColumnType int
+
+const (
+	// TextColumn compares fields by their normalized form.
+	TextColumn ColumnType = iota
+	// NaturalColumn compares fields by their normalized form with runs of
+	// digits compared by value, as by Natural.
+	NaturalColumn
+	// NumericColumn compares fields by the value of the first number in
+	// them, as parsed by a NumberFormat.
+	NumericColumn
+	// DateColumn compares fields as dates or times.
+	DateColumn
+)
+
+// A Column is a sort key of a RecordSorter.
+type Column struct {
+	// Index is the 0-based position of the field in each record.
+	Index int
+	// Name, if not empty, selects the field by its name in the header
+	// row instead of by Index.
+	Name string
+	Type ColumnType
+	// Normalize is the normalization function of a TextColumn or
+	// NaturalColumn. If nil, strings.ToLower is used.
+	Normalize func(string) string
+	// Format parses the numbers of a NumericColumn. If nil, English
+	// numbers are parsed.
+	Format *NumberFormat
+	// Layout is the time.Parse layout of a DateColumn. If empty, RFC 3339
+	// times and dates like "2006-01-02" are parsed.
+	Layout string
+	// Descending reverses the order of the Column.
+	Descending bool
+}
+
+// A RecordSorter sorts CSV records by one or more columns. Records that
+// compare equal by all of the columns are ordered by their fields. Fields
+// that are missing, or that a NumericColumn or DateColumn cannot parse,
+// sort last, whether or not the Column is Descending.
+type RecordSorter struct {
+	// Columns are the sort keys, from most to least significant.
+	Columns []Column
+	// Header reports whether the first record is a header row, which is
+	// kept first and names the fields for Column.Name.
+	Header bool
+	// MemoryLimit and TempDir are used by Sort as by an ExternalSorter.
+	MemoryLimit int
+	TempDir     string
+}
+
+// SortRecords sorts records in place.
+func (rs *RecordSorter) SortRecords(records [][]string) error {
+	body := records
+	var header []string
+	if rs.Header && len(records) > 0 {
+		header, body = records[0], records[1:]
+	}
+	key, err := rs.keyFunc(header)
+	if err != nil {
+		return err
+	}
+	items := make([]item, len(body))
+	for i, rec := range body {
+		items[i] = item{key(rec), encodeFields(rec)}
+	}
+	sort.Sort(&recordSlice{items, body})
+	return nil
+}
+
+type recordSlice struct {
+	items   []item
+	records [][]string
+}
+
+func (s *recordSlice) Len() int           { return len(s.items) }
+func (s *recordSlice) Less(i, j int) bool { return keyLess(&s.items[i], &s.items[j]) }
+func (s *recordSlice) Swap(i, j int) {
+	s.items[i], s.items[j] = s.items[j], s.items[i]
+	s.records[i], s.records[j] = s.records[j], s.records[i]
+}
+
+// Sort reads records from r and writes them in sorted order to w. Like an
+// ExternalSorter, it spills sorted runs to temporary files when the
+// records do not fit within MemoryLimit.
+func (rs *RecordSorter) Sort(w *csv.Writer, r *csv.Reader) error {
+	var header []string
+	if rs.Header {
+		var err error
+		header, err = r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = w.Write(header); err != nil {
+			return err
+		}
+	}
+	key, err := rs.keyFunc(header)
+	if err != nil {
+		return err
+	}
+
+	next := func() (item, error) {
+		rec, err := r.Read()
+		if err != nil {
+			return item{}, err
+		}
+		return item{key(rec), encodeFields(rec)}, nil
+	}
+	emit := func(raw string) error {
+		return w.Write(decodeFields(raw))
+	}
+	sorter := runSorter{less: keyLess, limit: rs.MemoryLimit, dir: rs.TempDir}
+	if err = sorter.sort(next, emit); err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// keyFunc returns a function that encodes the sort key of a record.
+func (rs *RecordSorter) keyFunc(header []string) (func([]string) string, error) {
+	cols := make([]Column, len(rs.Columns))
+	for i, col := range rs.Columns {
+		if col.Name != "" {
+			col.Index = -1
+			for j, name := range header {
+				if name == col.Name {
+					col.Index = j
+					break
+				}
+			}
+			if col.Index < 0 {
+				return nil, fmt.Errorf("normalizedsort: no column named %q", col.Name)
+			}
+		}
+		if col.Index < 0 {
+			return nil, fmt.Errorf("normalizedsort: invalid column index %d", col.Index)
+		}
+		switch col.Type {
+		case TextColumn, NaturalColumn:
+			if col.Normalize == nil {
+				col.Normalize = strings.ToLower
+			}
+		case NumericColumn:
+			if col.Format == nil {
+				col.Format = englishNumbers
+			}
+		case DateColumn:
+		default:
+			return nil, fmt.Errorf("normalizedsort: invalid column type %d", col.Type)
+		}
+		cols[i] = col
+	}
+	return func(rec []string) string {
+		var b []byte
+		for i := range cols {
+			b = cols[i].appendKey(b, rec)
+		}
+		return string(b)
+	}, nil
+}
+
+// Each column of a record key is a class byte, which sorts fields that
+// cannot be compared last, followed by an order-preserving encoding of
+// the field that is complemented for descending columns.
+const (
+	classValue   = 0
+	classInvalid = 1
+)
+
+func (col *Column) appendKey(dst []byte, rec []string) []byte {
+	if col.Index >= len(rec) {
+		return append(dst, classInvalid)
+	}
+	field := rec[col.Index]
+	dst = append(dst, classValue)
+	start := len(dst)
+	switch col.Type {
+	case TextColumn, NaturalColumn:
+		s := col.Normalize(field)
+		if col.Type == NaturalColumn {
+			s = Natural(s)
+		}
+		dst = append(appendEscaped(dst, s), keyEscape, keyTerminator)
+	case NumericColumn:
+		n, ok := firstNumber(col.Format, field)
+		if !ok {
+			dst[start-1] = classInvalid
+			return dst
+		}
+		dst = appendNumber(dst, n)
+	case DateColumn:
+		t, ok := col.parseTime(field)
+		if !ok {
+			dst[start-1] = classInvalid
+			return dst
+		}
+		dst = binary.BigEndian.AppendUint64(dst, uint64(t.Unix())^1<<63)
+		dst = binary.BigEndian.AppendUint32(dst, uint32(t.Nanosecond()))
+	}
+	if col.Descending {
+		complement(dst[start:])
+	}
+	return dst
+}
+
+func (col *Column) parseTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	layouts := []string{col.Layout}
+	if col.Layout == "" {
+		layouts = []string{time.RFC3339Nano, "2006-01-02"}
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func firstNumber(f *NumberFormat, s string) (number, bool) {
+	sc := numberScanner{f: f, s: s}
+	for {
+		seg, ok := sc.next()
+		if !ok {
+			return number{}, false
+		}
+		if seg.isNumber {
+			return seg.number, true
+		}
+	}
+}
+
+// appendNumber appends an order-preserving encoding of n: a sign byte,
+// then for positive numbers the biased exponent and the digits followed
+// by a terminator, which negative numbers complement.
+func appendNumber(dst []byte, n number) []byte {
+	switch n.sign() {
+	case 0:
+		return append(dst, 1)
+	case -1:
+		dst = append(dst, 0)
+	default:
+		dst = append(dst, 2)
+	}
+	start := len(dst)
+	dst = binary.BigEndian.AppendUint32(dst, uint32(n.exp)^1<<31)
+	dst = append(append(dst, n.digits...), 0)
+	if n.neg {
+		complement(dst[start:])
+	}
+	return dst
+}
+
+func complement(b []byte) {
+	for i := range b {
+		b[i] = ^b[i]
+	}
+}
+
+// encodeFields encodes a record as a string that orders like the record's
+// fields compared in turn, using the escaping of binary keys.
+func encodeFields(rec []string) string {
+	var b []byte
+	for _, field := range rec {
+		b = append(appendEscaped(b, field), keyEscape, keyTerminator)
+	}
+	return string(b)
+}
+
+func decodeFields(s string) []string {
+	var (
+		rec   []string
+		field strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		if s[i] != keyEscape {
+			field.WriteByte(s[i])
+			continue
+		}
+		i++
+		if s[i] == keyEscaped {
+			field.WriteByte(keyEscape)
+			continue
+		}
+		rec = append(rec, field.String())
+		field.Reset()
+	}
+	return rec
+}
@@ -0,0 +1,118 @@
+package normalizedsort_test
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/rand"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func ExampleRecordSorter_Sort() {
+	in := strings.NewReader(`name,joined,balance
+bob,2021-03-04,"1,200.50"
+Alice,2020-12-01,-3
+carol,2021-03-04,99
+Dave,unknown,1e3
+`)
+	rs := normalizedsort.RecordSorter{
+		Header: true,
+		Columns: []normalizedsort.Column{
+			{Name: "joined", Type: normalizedsort.DateColumn, Descending: true},
+			{Name: "balance", Type: normalizedsort.NumericColumn},
+		},
+	}
+	w := csv.NewWriter(os.Stdout)
+	if err := rs.Sort(w, csv.NewReader(in)); err != nil {
+		panic(err)
+	}
+	// Output:
+	// name,joined,balance
+	// carol,2021-03-04,99
+	// bob,2021-03-04,"1,200.50"
+	// Alice,2020-12-01,-3
+	// Dave,unknown,1e3
+}
+
+func ExampleRecordSorter_SortRecords() {
+	records := [][]string{
+		{"file10.txt", "Text"},
+		{"File2.txt", "text"},
+		{"file1.txt", "Data"},
+	}
+	rs := normalizedsort.RecordSorter{Columns: []normalizedsort.Column{
+		{Index: 1},
+		{Index: 0, Type: normalizedsort.NaturalColumn},
+	}}
+	if err := rs.SortRecords(records); err != nil {
+		panic(err)
+	}
+	fmt.Println(records)
+	// Output: [[file1.txt Data] [File2.txt text] [file10.txt Text]]
+}
+
+func TestRecordSorter(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	names := randomLines(3000)
+	records := [][]string{{"name", "score", "date"}}
+	for _, name := range names {
+		records = append(records, []string{
+			name,
+			strconv.FormatFloat(r.NormFloat64()*1000, 'f', r.Intn(3), 64),
+			fmt.Sprintf("20%02d-%02d-%02d", r.Intn(30), 1+r.Intn(12), 1+r.Intn(28)),
+		})
+	}
+	rs := normalizedsort.RecordSorter{
+		Header: true,
+		Columns: []normalizedsort.Column{
+			{Name: "date", Type: normalizedsort.DateColumn},
+			{Name: "score", Type: normalizedsort.NumericColumn, Descending: true},
+			{Name: "name", Normalize: normalizedsort.CaseInsensitiveTrimSpace},
+		},
+	}
+
+	want := append([][]string(nil), records...)
+	if err := rs.SortRecords(want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want[0], records[0]) {
+		t.Fatalf("header moved: %q", want[0])
+	}
+	for i := 2; i < len(want); i++ {
+		a, b := want[i-1], want[i]
+		if a[2] > b[2] {
+			t.Fatalf("dates out of order: %q before %q", a, b)
+		}
+		if a[2] == b[2] && normalizedsort.CompareNumeric(a[1], b[1]) < 0 {
+			t.Fatalf("scores out of order: %q before %q", a, b)
+		}
+	}
+
+	var in strings.Builder
+	csv.NewWriter(&in).WriteAll(records)
+	for _, limit := range []int{0, 2000} {
+		rs.MemoryLimit = limit
+		rs.TempDir = t.TempDir()
+		var out strings.Builder
+		if err := rs.Sort(csv.NewWriter(&out), csv.NewReader(strings.NewReader(in.String()))); err != nil {
+			t.Fatal(err)
+		}
+		got, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Sort with MemoryLimit %d does not match SortRecords", limit)
+		}
+	}
+
+	rs.Columns = []normalizedsort.Column{{Name: "missing"}}
+	if err := rs.SortRecords(records); err == nil {
+		t.Error("SortRecords with unknown column succeeded")
+	}
+}
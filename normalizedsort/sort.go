@@ -57,6 +57,70 @@ func (ns *normalizedStringSlice) Swap(i, j int) {
 	ns.normalized[i], ns.normalized[j] = ns.normalized[j], ns.normalized[i]
 }
 
+// SortFunc is like Sort, but orders ss by a comparison function instead of a
+// normalization function. cmp must return a negative number if a sorts before
+// b, a positive number if it sorts after, and zero if they are equivalent.
+func SortFunc(ss []string, cmp func(a, b string) int) {
+	sort.Sort(NewFunc(ss, cmp))
+}
+
+// NewFunc returns a sort.Interface that sorts according to cmp. As with New,
+// strings that cmp considers equivalent are sorted according to their raw form.
+func NewFunc(ss []string, cmp func(a, b string) int) sort.Interface {
+	return &funcStringSlice{ss, cmp}
+}
+
+type funcStringSlice struct {
+	ss  []string
+	cmp func(a, b string) int
+}
+
+// Len is the number of elements in the collection.
+func (fs *funcStringSlice) Len() int {
+	return len(fs.ss)
+}
+
+// Less reports whether the element with
+// index i should sort before the element with index j.
+func (fs *funcStringSlice) Less(i, j int) bool {
+	if c := fs.cmp(fs.ss[i], fs.ss[j]); c != 0 {
+		return c < 0
+	}
+	return fs.ss[i] < fs.ss[j]
+}
+
+// Swap swaps the elements with indexes i and j.
+func (fs *funcStringSlice) Swap(i, j int) {
+	fs.ss[i], fs.ss[j] = fs.ss[j], fs.ss[i]
+}
+
+// Search returns the index in ss, which must be sorted by Sort with the same
+// normalize function, at which s is or would be inserted. If normalize is
+// nil, strings.ToLower is used, as in New.
+func Search(ss []string, s string, normalize func(string) string) int {
+	if normalize == nil {
+		normalize = strings.ToLower
+	}
+	key := normalize(s)
+	return sort.Search(len(ss), func(i int) bool {
+		k := normalize(ss[i])
+		if k == key {
+			return ss[i] >= s
+		}
+		return k > key
+	})
+}
+
+// SearchFunc is like Search for a slice sorted by SortFunc with cmp.
+func SearchFunc(ss []string, s string, cmp func(a, b string) int) int {
+	return sort.Search(len(ss), func(i int) bool {
+		if c := cmp(ss[i], s); c != 0 {
+			return c > 0
+		}
+		return ss[i] >= s
+	})
+}
+
 // CaseInsensitiveTrimSpace calls strings.TrimSpace and strings.ToLower to normalize its input.
 func CaseInsensitiveTrimSpace(s string) string {
 	return strings.ToLower(strings.TrimSpace(s))
@@ -19,3 +19,11 @@ func ExampleCaseInsensitiveTrimSpace() {
 	fmt.Printf("%q\n", slice)
 	// Output: ["Aardvark" "aardvark" "  Hello" "hello" "World!"]
 }
+
+func ExampleSearch() {
+	slice := []string{"Aardvark", "hello", "aardvark", "  Hello", "World!"}
+	normalizedsort.Sort(slice, nil)
+	i := normalizedsort.Search(slice, "Hello", nil)
+	fmt.Println(i, slice[i])
+	// Output: 3 hello
+}
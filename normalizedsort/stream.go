@@ -0,0 +1,94 @@
+package normalizedsort
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A Strategy selects how NewMap and SortMap compare strings.
+type Strategy int
+
+const (
+	// CacheKeys normalizes every string once up front, as New does. It is
+	// usually fastest, but holds a normalized copy of the whole slice.
+	CacheKeys Strategy = iota
+	// Stream normalizes strings rune by rune during each comparison,
+	// without allocating. It repeats the normalization in every
+	// comparison, so it is only faster for small slices of long strings
+	// that differ early, such as 100 strings of 1KB. Otherwise it wins
+	// only on memory, and is slower still as strings share longer
+	// prefixes: up to 13 times slower for 100000 strings with 64-byte
+	// prefixes, while caching holds about 100MB for 100000 strings of 1KB.
+	Stream
+)
+
+// CompareMap compares a and b as if each had been normalized with
+// strings.Map(mapping, ·), returning -1, 0, or +1, but without allocating.
+// As in strings.Map, runes for which mapping returns a negative value are
+// dropped.
+func CompareMap(a, b string, mapping func(rune) rune) int {
+	for {
+		ra, na := nextMapped(a, mapping)
+		rb, nb := nextMapped(b, mapping)
+		a, b = a[na:], b[nb:]
+		switch {
+		case ra < 0 && rb < 0:
+			return 0
+		case ra != rb:
+			// Comparing code points orders like comparing their UTF-8 encodings.
+			if ra < rb {
+				return -1
+			}
+			return 1
+		}
+	}
+}
+
+// nextMapped returns the first rune of s that mapping does not drop,
+// mapped, and the number of bytes consumed, or -1 at the end of s.
+func nextMapped(s string, mapping func(rune) rune) (rune, int) {
+	n := 0
+	for n < len(s) {
+		r, size := rune(s[n]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRuneInString(s[n:])
+		}
+		n += size
+		if r = mapping(r); r >= 0 {
+			if !utf8.ValidRune(r) {
+				r = utf8.RuneError
+			}
+			return r, n
+		}
+	}
+	return -1, n
+}
+
+// CompareFold compares a and b case-insensitively by comparing them rune by
+// rune in lower case, as they would compare after strings.ToLower, without
+// allocating. It returns -1, 0, or +1.
+func CompareFold(a, b string) int {
+	return CompareMap(a, b, unicode.ToLower)
+}
+
+// NewMap returns a sort.Interface that sorts like New with the normalization
+// function func(s string) string { return strings.Map(mapping, s) }, using
+// strategy to trade memory for speed. Strings that normalize alike are
+// sorted by their raw form.
+func NewMap(ss []string, mapping func(rune) rune, strategy Strategy) sort.Interface {
+	if strategy == Stream {
+		return NewFunc(ss, func(a, b string) int {
+			return CompareMap(a, b, mapping)
+		})
+	}
+	return New(ss, func(s string) string {
+		return strings.Map(mapping, s)
+	})
+}
+
+// SortMap is a convenience method that calls NewMap then sort.Sort.
+func SortMap(ss []string, mapping func(rune) rune, strategy Strategy) {
+	sort.Sort(NewMap(ss, mapping, strategy))
+}
@@ -0,0 +1,240 @@
+package normalizedsort
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A Scheme is a table for transliterating one script into Latin letters.
+// Characters the Scheme does not cover are left unchanged, so several
+// Schemes can be applied in turn to mixed-script text.
+type Scheme struct {
+	// Name identifies the Scheme, e.g. "ISO 9".
+	Name string
+	// Reversible reports whether the Scheme maps distinct letters to
+	// distinct spellings, so that Reverse recovers the original text.
+	Reversible bool
+	// table maps lower-case source letters and digraphs to their spelling.
+	table map[string]string
+	// maxLen is the length in runes of the longest key of table.
+	maxLen int
+	// reverse is the inverse of table for reversible schemes.
+	reverse       map[string]string
+	maxReverseLen int
+}
+
+func newScheme(name string, reversible bool, table map[string]string) *Scheme {
+	s := Scheme{Name: name, Reversible: reversible, table: table}
+	for k := range table {
+		if n := utf8.RuneCountInString(k); n > s.maxLen {
+			s.maxLen = n
+		}
+	}
+	if reversible {
+		s.reverse = make(map[string]string, len(table))
+		for k, v := range table {
+			s.reverse[v] = k
+			if n := utf8.RuneCountInString(v); n > s.maxReverseLen {
+				s.maxReverseLen = n
+			}
+		}
+	}
+	return &s
+}
+
+// Transliteration schemes bundled with the package.
+var (
+	// ISO9 transliterates Cyrillic according to ISO 9:1995, which maps
+	// each letter to a single Latin letter, with diacritics where needed.
+	// It is reversible, except that the hard and soft signs map to
+	// caseless modifier letters and so always reverse to lower case.
+	ISO9 = newScheme("ISO 9", true, map[string]string{
+		"а": "a", "б": "b", "в": "v", "г": "g", "д": "d", "е": "e",
+		"ё": "ë", "ж": "ž", "з": "z", "и": "i", "й": "j", "к": "k",
+		"л": "l", "м": "m", "н": "n", "о": "o", "п": "p", "р": "r",
+		"с": "s", "т": "t", "у": "u", "ф": "f", "х": "h", "ц": "c",
+		"ч": "č", "ш": "š", "щ": "ŝ", "ъ": "ʺ", "ы": "y", "ь": "ʹ",
+		"э": "è", "ю": "û", "я": "â",
+		// Ukrainian, Belarusian, Serbian, and Macedonian letters.
+		"ґ": "g̀", "є": "ê", "і": "ì", "ї": "ï", "ў": "ŭ", "ђ": "đ",
+		"ј": "ǰ", "љ": "l̂", "њ": "n̂", "ћ": "ć", "џ": "d̂", "ѓ": "ǵ",
+		"ќ": "ḱ", "ѕ": "ẑ",
+	})
+
+	// ICAO transliterates Cyrillic into plain ASCII as in the machine
+	// readable zone of passports, according to ICAO Doc 9303.
+	ICAO = newScheme("ICAO 9303", false, map[string]string{
+		"а": "a", "б": "b", "в": "v", "г": "g", "д": "d", "е": "e",
+		"ё": "e", "ж": "zh", "з": "z", "и": "i", "й": "i", "к": "k",
+		"л": "l", "м": "m", "н": "n", "о": "o", "п": "p", "р": "r",
+		"с": "s", "т": "t", "у": "u", "ф": "f", "х": "kh", "ц": "ts",
+		"ч": "ch", "ш": "sh", "щ": "shch", "ъ": "ie", "ы": "y", "ь": "",
+		"э": "e", "ю": "iu", "я": "ia",
+		"ґ": "g", "є": "ie", "і": "i", "ї": "i", "ў": "u", "ђ": "d",
+		"ј": "j", "љ": "lj", "њ": "nj", "ћ": "c", "џ": "dz", "ѓ": "g",
+		"ќ": "k", "ѕ": "dz",
+	})
+
+	// ELOT743 transcribes modern Greek into plain ASCII according to
+	// ELOT 743 (ISO 843 type 2), including its common digraphs.
+	ELOT743 = newScheme("ELOT 743", false, map[string]string{
+		"α": "a", "β": "v", "γ": "g", "δ": "d", "ε": "e", "ζ": "z",
+		"η": "i", "θ": "th", "ι": "i", "κ": "k", "λ": "l", "μ": "m",
+		"ν": "n", "ξ": "x", "ο": "o", "π": "p", "ρ": "r", "σ": "s",
+		"ς": "s", "τ": "t", "υ": "y", "φ": "f", "χ": "ch", "ψ": "ps",
+		"ω": "o",
+		"ά": "a", "έ": "e", "ή": "i", "ί": "i", "ό": "o", "ύ": "y",
+		"ώ": "o", "ϊ": "i", "ϋ": "y", "ΐ": "i", "ΰ": "y",
+		"αυ": "av", "αύ": "av", "ευ": "ev", "εύ": "ev", "ηυ": "iv",
+		"ηύ": "iv", "ου": "ou", "ού": "ou", "γγ": "ng", "γκ": "gk",
+		"γξ": "nx", "γχ": "nch", "μπ": "mp",
+	})
+
+	// LatinASCII replaces Latin letters with diacritics and ligatures
+	// with their closest ASCII spelling, like FoldDiacritics.
+	LatinASCII = newScheme("Latin-ASCII", false, func() map[string]string {
+		m := make(map[string]string, len(foldTable))
+		for r, s := range foldTable {
+			m[string(r)] = s
+		}
+		return m
+	}())
+)
+
+// Transliterate applies the Scheme to s. Letters keep their case: an
+// upper-case letter is spelled with an initial capital, so "Щукин"
+// becomes "Shchukin" in ICAO, unless it is part of an upper-case word,
+// so "ЩУКИН" becomes "SHCHUKIN".
+func (sc *Scheme) Transliterate(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	prev := rune(-1)
+	for s != "" {
+		src, dst, ok := longestMatch(sc.table, sc.maxLen, s, unicode.ToLower)
+		if !ok {
+			b.WriteString(src)
+			s = s[len(src):]
+			prev, _ = utf8.DecodeLastRuneInString(src)
+			continue
+		}
+		b.WriteString(matchCase(dst, src, prev, s[len(src):]))
+		s = s[len(src):]
+		prev, _ = utf8.DecodeLastRuneInString(src)
+	}
+	return b.String()
+}
+
+// ErrIrreversible is returned by Scheme.Reverse for schemes that are not
+// Reversible.
+var ErrIrreversible = errors.New("normalizedsort: transliteration scheme is not reversible")
+
+// Reverse undoes Transliterate for a Reversible Scheme, returning the
+// text in its original script.
+func (sc *Scheme) Reverse(s string) (string, error) {
+	if !sc.Reversible {
+		return "", ErrIrreversible
+	}
+	var b strings.Builder
+	b.Grow(2 * len(s))
+	prev := rune(-1)
+	for s != "" {
+		src, dst, ok := longestMatch(sc.reverse, sc.maxReverseLen, s, unicode.ToLower)
+		if !ok {
+			b.WriteString(src)
+			s = s[len(src):]
+			prev, _ = utf8.DecodeLastRuneInString(src)
+			continue
+		}
+		b.WriteString(matchCase(dst, src, prev, s[len(src):]))
+		s = s[len(src):]
+		prev, _ = utf8.DecodeLastRuneInString(src)
+	}
+	return b.String(), nil
+}
+
+// longestMatch finds the longest prefix of s of at most maxLen runes
+// that, mapped by lower, is a key of table. If there is none, it returns
+// the first rune of s as src and false.
+func longestMatch(table map[string]string, maxLen int, s string, lower func(rune) rune) (src, dst string, ok bool) {
+	ends := make([]int, 0, maxLen)
+	for i := range s {
+		if i > 0 {
+			ends = append(ends, i)
+		}
+		if len(ends) == maxLen {
+			break
+		}
+	}
+	if len(ends) < maxLen {
+		ends = append(ends, len(s))
+	}
+	for n := len(ends) - 1; n >= 0; n-- {
+		prefix := s[:ends[n]]
+		if dst, ok := table[strings.Map(lower, prefix)]; ok {
+			return prefix, dst, true
+		}
+	}
+	_, size := utf8.DecodeRuneInString(s)
+	return s[:size], "", false
+}
+
+// matchCase gives dst the case of src, which was followed by rest and
+// preceded by prev. A digraph in capitals, or a capital next to another,
+// is spelled in capitals; any other capital, with an initial capital.
+func matchCase(dst, src string, prev rune, rest string) string {
+	first, _ := utf8.DecodeRuneInString(src)
+	if !unicode.IsUpper(first) {
+		return dst
+	}
+	letters := 0
+	for _, r := range src {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if !unicode.IsUpper(r) {
+			return titleCase(dst)
+		}
+		letters++
+	}
+	next, _ := utf8.DecodeRuneInString(rest)
+	if letters > 1 || unicode.IsUpper(next) || !unicode.IsLetter(next) && unicode.IsUpper(prev) {
+		return strings.ToUpper(dst)
+	}
+	return titleCase(dst)
+}
+
+func titleCase(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// Transliterate normalizes s for sorting mixed-script text. It spells
+// Cyrillic with ICAO and Greek with ELOT743, removes diacritics, and
+// lower-cases the result, so that "Дмитрий" sorts next to "Dmitry".
+func Transliterate(s string) string {
+	return defaultTransliterate(s)
+}
+
+var defaultTransliterate = TransliterateFunc(ICAO, ELOT743)
+
+// TransliterateFunc returns a normalizer like Transliterate that applies
+// schemes in turn before removing diacritics and lower-casing.
+func TransliterateFunc(schemes ...*Scheme) func(string) string {
+	return func(s string) string {
+		for _, sc := range schemes {
+			s = sc.Transliterate(s)
+		}
+		s = LatinASCII.Transliterate(s)
+		return strings.Map(func(r rune) rune {
+			if unicode.Is(unicode.Mn, r) {
+				return -1
+			}
+			return unicode.ToLower(r)
+		}, s)
+	}
+}
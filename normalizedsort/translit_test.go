@@ -0,0 +1,165 @@
+package normalizedsort_test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func ExampleTransliterate() {
+	slice := []string{"Dmitry", "Дмитрий", "Alexandra", "Αλεξάνδρα", "Ελένη", "Elena"}
+	normalizedsort.Sort(slice, normalizedsort.Transliterate)
+	fmt.Printf("%q\n", slice)
+	// Output: ["Alexandra" "Αλεξάνδρα" "Дмитрий" "Dmitry" "Elena" "Ελένη"]
+}
+
+func ExampleScheme_Reverse() {
+	latin := normalizedsort.ISO9.Transliterate("Щедрин")
+	cyrillic, _ := normalizedsort.ISO9.Reverse(latin)
+	fmt.Println(latin, cyrillic)
+	// Output: Ŝedrin Щедрин
+}
+
+func TestSchemes(t *testing.T) {
+	for _, tc := range []struct {
+		scheme   *normalizedsort.Scheme
+		in, want string
+	}{
+		{normalizedsort.ICAO, "Дмитрий Щукин", "Dmitrii Shchukin"},
+		{normalizedsort.ICAO, "Юлия Жукова", "Iuliia Zhukova"},
+		{normalizedsort.ICAO, "Київ", "Kiiv"},
+		{normalizedsort.ICAO, "Ильич", "Ilich"},
+		{normalizedsort.ISO9, "Ёлка, Київ", "Ëlka, Kiïv"},
+		{normalizedsort.ELOT743, "Αθήνα", "Athina"},
+		{normalizedsort.ELOT743, "Ευρώπη", "Evropi"},
+		{normalizedsort.ELOT743, "ΟΥΡΑΝΟΣ", "OURANOS"},
+		{normalizedsort.ELOT743, "Ουρανός", "Ouranos"},
+		{normalizedsort.ICAO, "ЩУКИН Щ. ЛЕЩ", "SHCHUKIN Shch. LESHCH"},
+		{normalizedsort.ELOT743, "Άγγελος", "Angelos"},
+		{normalizedsort.LatinASCII, "Dvořák", "Dvorak"},
+	} {
+		if got := tc.scheme.Transliterate(tc.in); got != tc.want {
+			t.Errorf("%s.Transliterate(%q) = %q; want %q", tc.scheme.Name, tc.in, got, tc.want)
+		}
+	}
+	if back, _ := normalizedsort.ISO9.Reverse("ŜUKIN"); back != "ЩУКИН" {
+		t.Errorf("ISO9.Reverse(%q) = %q", "ŜUKIN", back)
+	}
+	if _, err := normalizedsort.ICAO.Reverse("Shchukin"); err != normalizedsort.ErrIrreversible {
+		t.Errorf("ICAO.Reverse: got %v", err)
+	}
+}
+
+func TestISO9RoundTrip(t *testing.T) {
+	var letters []rune
+	for r := 'а'; r <= 'я'; r++ {
+		letters = append(letters, r)
+	}
+	letters = append(letters, []rune("ёґєіїўђјљњћџѓќѕ")...)
+	for _, r := range letters {
+		for _, s := range []string{string(r), string(r - 'а' + 'А'), "д" + string(r) + "а"} {
+			if r >= 'ѐ' || r == 'ъ' || r == 'ь' {
+				// Letters outside а-я have no upper-case form at r-'а'+'А',
+				// and the signs map to caseless modifier letters.
+				s = string(r)
+			}
+			latin := normalizedsort.ISO9.Transliterate(s)
+			if latin == s {
+				t.Errorf("ISO9 does not map %q", s)
+			}
+			if back, err := normalizedsort.ISO9.Reverse(latin); err != nil || back != s {
+				t.Errorf("ISO9 round trip %q -> %q -> %q (%v)", s, latin, back, err)
+			}
+		}
+	}
+	for _, s := range []string{"Съешь же ещё этих мягких французских булок", "Љубљана Њујорк Џеп", "Ґанок і їжак"} {
+		latin := normalizedsort.ISO9.Transliterate(s)
+		if back, _ := normalizedsort.ISO9.Reverse(latin); back != s {
+			t.Errorf("ISO9 round trip %q -> %q -> %q", s, latin, back)
+		}
+	}
+}
+
+// collisions transliterates each of srcs and returns, for each spelling
+// shared by more than one of them, the sources that collapse into it.
+func collisions(t *testing.T, sc *normalizedsort.Scheme, srcs []string) map[string]string {
+	groups := make(map[string][]string)
+	for _, src := range srcs {
+		latin := sc.Transliterate(src)
+		if latin == src {
+			t.Errorf("%s does not map %q", sc.Name, src)
+		}
+		if again := sc.Transliterate(latin); again != latin {
+			t.Errorf("%s maps its own output %q to %q", sc.Name, latin, again)
+		}
+		// A lone capital is spelled with an initial capital; a digraph in
+		// capitals, in capitals.
+		upper, want := strings.ToUpper(src), strings.ToUpper(latin)
+		if len([]rune(src)) == 1 && latin != "" {
+			want = strings.ToUpper(latin[:1]) + latin[1:]
+		}
+		if got := sc.Transliterate(upper); upper != src && got != want {
+			t.Errorf("%s.Transliterate(%q) = %q; want %q", sc.Name, upper, got, want)
+		}
+		groups[latin] = append(groups[latin], src)
+	}
+	m := make(map[string]string)
+	for latin, g := range groups {
+		if len(g) > 1 {
+			sort.Strings(g)
+			m[latin] = strings.Join(g, " ")
+		}
+	}
+	return m
+}
+
+func TestLossySchemes(t *testing.T) {
+	split := func(s string) []string { return strings.Split(s, "") }
+	var cyrillic, greek, latin []string
+	for r := 'а'; r <= 'я'; r++ {
+		cyrillic = append(cyrillic, string(r))
+	}
+	cyrillic = append(cyrillic, split("ёґєіїўђјљњћџѓќѕ")...)
+	for r := 'α'; r <= 'ω'; r++ {
+		greek = append(greek, string(r))
+	}
+	greek = append(greek, split("άέήίόύώϊϋΐΰ")...)
+	greek = append(greek, strings.Fields("αυ αύ ευ εύ ηυ ηύ ου ού γγ γκ γξ γχ μπ")...)
+	for r := rune(0xdf); r <= 0x17f; r++ {
+		if unicode.IsLower(r) && r != 'ĸ' {
+			latin = append(latin, string(r))
+		}
+	}
+
+	for _, tc := range []struct {
+		scheme *normalizedsort.Scheme
+		srcs   []string
+		want   map[string]string
+	}{
+		{normalizedsort.ICAO, cyrillic, map[string]string{
+			"d": "д ђ", "dz": "ѕ џ", "e": "е э ё", "g": "г ѓ ґ",
+			"i": "и й і ї", "ie": "ъ є", "k": "к ќ", "u": "у ў",
+		}},
+		{normalizedsort.ELOT743, greek, map[string]string{
+			"a": "ά α", "av": "αυ αύ", "e": "έ ε", "ev": "ευ εύ",
+			"i": "ΐ ή ί η ι ϊ", "iv": "ηυ ηύ", "o": "ο ω ό ώ", "ou": "ου ού",
+			"s": "ς σ", "y": "ΰ υ ϋ ύ",
+		}},
+		{normalizedsort.LatinASCII, latin, map[string]string{
+			"a": "à á â ã ä å ā ă ą", "c": "ç ć ĉ ċ č", "d": "ð ď đ",
+			"e": "è é ê ë ē ĕ ė ę ě", "g": "ĝ ğ ġ ģ", "h": "ĥ ħ",
+			"i": "ì í î ï ĩ ī ĭ į ı", "l": "ĺ ļ ľ ŀ ł", "n": "ñ ń ņ ň ŉ",
+			"o": "ò ó ô õ ö ø ō ŏ ő", "r": "ŕ ŗ ř", "s": "ś ŝ ş š ſ",
+			"t": "ţ ť ŧ", "u": "ù ú û ü ũ ū ŭ ů ű ų", "y": "ý ÿ ŷ", "z": "ź ż ž",
+		}},
+	} {
+		got := collisions(t, tc.scheme, tc.srcs)
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s collapses %v; want %v", tc.scheme.Name, got, tc.want)
+		}
+	}
+}
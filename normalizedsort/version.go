@@ -0,0 +1,344 @@
+package normalizedsort
+
+import "strings"
+
+// CompareSemVer compares a and b by Semantic Versioning 2.0 precedence,
+// returning -1, 0, or +1. A leading "v" is ignored, as is build metadata.
+// Strings that are not valid semantic versions sort after all valid ones
+// and are compared with each other byte-wise.
+//
+// See https://semver.org/spec/v2.0.0.html#spec-item-11
+func CompareSemVer(a, b string) int {
+	va, okA := parseSemVer(a)
+	vb, okB := parseSemVer(b)
+	switch {
+	case !okA && !okB:
+		return strings.Compare(a, b)
+	case !okA:
+		return 1
+	case !okB:
+		return -1
+	}
+	for i := range va.core {
+		if c := compareDigits(va.core[i], vb.core[i]); c != 0 {
+			return c
+		}
+	}
+	// A version without a pre-release has higher precedence than one with.
+	switch {
+	case len(va.pre) == 0 && len(vb.pre) == 0:
+		return 0
+	case len(va.pre) == 0:
+		return 1
+	case len(vb.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(va.pre) && i < len(vb.pre); i++ {
+		x, y := va.pre[i], vb.pre[i]
+		xNum, yNum := isDigits(x), isDigits(y)
+		var c int
+		switch {
+		case xNum && yNum:
+			c = compareDigits(x, y)
+		case xNum:
+			c = -1
+		case yNum:
+			c = 1
+		default:
+			c = strings.Compare(x, y)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return sign(len(va.pre) - len(vb.pre))
+}
+
+type semVer struct {
+	core [3]string
+	pre  []string
+}
+
+func parseSemVer(s string) (v semVer, ok bool) {
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		if !validIdents(s[i+1:], false) {
+			return v, false
+		}
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		if !validIdents(s[i+1:], true) {
+			return v, false
+		}
+		v.pre = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+	core := strings.Split(s, ".")
+	if len(core) != 3 {
+		return v, false
+	}
+	for i, n := range core {
+		if !isDigits(n) || len(n) > 1 && n[0] == '0' {
+			return v, false
+		}
+		v.core[i] = n
+	}
+	return v, true
+}
+
+// validIdents reports whether s is a dot-separated list of SemVer
+// identifiers. Numeric pre-release identifiers may not have leading zeros.
+func validIdents(s string, pre bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for i := 0; i < len(id); i++ {
+			if c := id[i]; !isAlnum(c) && c != '-' {
+				return false
+			}
+		}
+		if pre && isDigits(id) && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+// CompareDebian compares a and b as Debian package versions of the form
+// [epoch:]upstream[-revision], returning a negative number, zero, or a
+// positive number like dpkg --compare-versions. A tilde sorts before
+// anything, even the end of the string, so "1.0~rc1" precedes "1.0".
+//
+// See https://www.debian.org/doc/debian-policy/ch-controlfields.html#version
+func CompareDebian(a, b string) int {
+	ea, ua, ra := splitEVR(a)
+	eb, ub, rb := splitEVR(b)
+	if c := compareDigits(ea, eb); c != 0 {
+		return c
+	}
+	if c := verrevcmp(ua, ub); c != 0 {
+		return sign(c)
+	}
+	return sign(verrevcmp(ra, rb))
+}
+
+// splitEVR splits a version into its epoch (digits only, defaulting to
+// "0"), version, and release or revision after the last hyphen.
+func splitEVR(s string) (epoch, version, release string) {
+	epoch = "0"
+	if i := strings.IndexByte(s, ':'); i >= 0 && isDigits(s[:i]) {
+		epoch, s = strings.TrimLeft(s[:i], "0"), s[i+1:]
+	}
+	if i := strings.LastIndexByte(s, '-'); i >= 0 {
+		s, release = s[:i], s[i+1:]
+	}
+	return epoch, s, release
+}
+
+// verrevcmp is a port of dpkg's comparison of upstream versions and
+// Debian revisions.
+func verrevcmp(a, b string) int {
+	order := func(s string, i int) int {
+		if i >= len(s) {
+			return 0
+		}
+		switch c := s[i]; {
+		case isDigit(c):
+			return 0
+		case isAlpha(c):
+			return int(c)
+		case c == '~':
+			return -1
+		default:
+			return int(c) + 256
+		}
+	}
+	digitAt := func(s string, i int) bool {
+		return i < len(s) && isDigit(s[i])
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		firstDiff := 0
+		for i < len(a) && !isDigit(a[i]) || j < len(b) && !isDigit(b[j]) {
+			ac, bc := order(a, i), order(b, j)
+			if ac != bc {
+				return ac - bc
+			}
+			i++
+			j++
+		}
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		for digitAt(a, i) && digitAt(b, j) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if digitAt(a, i) {
+			return 1
+		}
+		if digitAt(b, j) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+// CompareRPM compares a and b as RPM package versions of the form
+// [epoch:]version[-release], returning -1, 0, or +1 like rpmvercmp.
+// Segments are compared alternately as numbers and letters, separators
+// are ignored, a tilde sorts before anything, and a caret sorts after
+// the end of the string but before anything else.
+//
+// See https://rpm-software-management.github.io/rpm/manual/dependencies.html#versioning
+func CompareRPM(a, b string) int {
+	ea, va, ra := splitEVR(a)
+	eb, vb, rb := splitEVR(b)
+	if c := compareDigits(ea, eb); c != 0 {
+		return c
+	}
+	if c := rpmvercmp(va, vb); c != 0 {
+		return c
+	}
+	return rpmvercmp(ra, rb)
+}
+
+// rpmvercmp is a port of RPM's version segment comparison.
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+	at := func(s string, i int) byte {
+		if i < len(s) {
+			return s[i]
+		}
+		return 0
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for i < len(a) && !isAlnum(a[i]) && a[i] != '~' && a[i] != '^' {
+			i++
+		}
+		for j < len(b) && !isAlnum(b[j]) && b[j] != '~' && b[j] != '^' {
+			j++
+		}
+
+		if at(a, i) == '~' || at(b, j) == '~' {
+			if at(a, i) != '~' {
+				return 1
+			}
+			if at(b, j) != '~' {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		if at(a, i) == '^' || at(b, j) == '^' {
+			switch {
+			case i == len(a):
+				return -1
+			case j == len(b):
+				return 1
+			case a[i] != '^':
+				return 1
+			case b[j] != '^':
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		if i == len(a) || j == len(b) {
+			break
+		}
+
+		x, y := i, j
+		isNum := isDigit(a[x])
+		class := isAlpha
+		if isNum {
+			class = isDigit
+		}
+		for x < len(a) && class(a[x]) {
+			x++
+		}
+		for y < len(b) && class(b[y]) {
+			y++
+		}
+		// The segments are of different types: numeric is newer.
+		if y == j {
+			if isNum {
+				return 1
+			}
+			return -1
+		}
+
+		var c int
+		if isNum {
+			c = compareDigits(a[i:x], b[j:y])
+		} else {
+			c = strings.Compare(a[i:x], b[j:y])
+		}
+		if c != 0 {
+			return c
+		}
+		i, j = x, y
+	}
+	switch {
+	case i >= len(a) && j >= len(b):
+		return 0
+	case i >= len(a):
+		return -1
+	}
+	return 1
+}
+
+// compareDigits compares two non-negative decimal integers of any length.
+func compareDigits(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return sign(len(a) - len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func isAlpha(c byte) bool { return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' }
+
+func isAlnum(c byte) bool { return isDigit(c) || isAlpha(c) }
@@ -0,0 +1,117 @@
+package normalizedsort_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/carlmjohnson/go-utils/normalizedsort"
+)
+
+func ExampleCompareSemVer() {
+	slice := []string{"v1.10.0", "v1.9.0", "v1.10.0-rc.1", "v1.2.3"}
+	normalizedsort.SortFunc(slice, normalizedsort.CompareSemVer)
+	fmt.Printf("%q\n", slice)
+	fmt.Println(normalizedsort.SearchFunc(slice, "v1.9.1", normalizedsort.CompareSemVer))
+	// Output: ["v1.2.3" "v1.9.0" "v1.10.0-rc.1" "v1.10.0"]
+	// 2
+}
+
+func testCompare(t *testing.T, name string, cmp func(a, b string) int, tests []struct {
+	a, b string
+	want int
+}) {
+	t.Helper()
+	for _, tc := range tests {
+		if got := cmp(tc.a, tc.b); got != tc.want {
+			t.Errorf("%s(%q, %q) = %d; want %d", name, tc.a, tc.b, got, tc.want)
+		}
+		if got := cmp(tc.b, tc.a); got != -tc.want {
+			t.Errorf("%s(%q, %q) = %d; want %d", name, tc.b, tc.a, got, -tc.want)
+		}
+	}
+}
+
+func TestCompareSemVer(t *testing.T) {
+	// Precedence example from the SemVer 2.0.0 specification, item 11.
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "2.0.0",
+		"2.1.0", "2.1.1", "not-a-version",
+	}
+	for i := 0; i < len(ordered)-1; i++ {
+		if c := normalizedsort.CompareSemVer(ordered[i], ordered[i+1]); c != -1 {
+			t.Errorf("CompareSemVer(%q, %q) = %d; want -1", ordered[i], ordered[i+1], c)
+		}
+	}
+	testCompare(t, "CompareSemVer", normalizedsort.CompareSemVer, []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0+build.1", "1.0.0+build.2", 0},
+		{"v1.0.0", "1.0.0", 0},
+		{"1.0.0-alpha.01", "1.0.0", 1},
+		{"01.0.0", "1.0.0", 1},
+		{"99999999999999999999.0.0", "9.0.0", 1},
+	})
+}
+
+func TestCompareDebian(t *testing.T) {
+	// Vectors from dpkg's t-version.c.
+	testCompare(t, "CompareDebian", normalizedsort.CompareDebian, []struct {
+		a, b string
+		want int
+	}{
+		{"0", "0", 0},
+		{"0", "00", 0},
+		{"1", "2", -1},
+		{"2:0", "1:9", 1},
+		{"0:0", "0", 0},
+		{"0-0", "0", 0},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0~~", "1.0~", -1},
+		{"1.0~~a", "1.0~~", 1},
+		{"1.0~", "1.0~a", -1},
+		{"1.0", "1.0a", -1},
+		{"1.0a", "1.0+", -1},
+		{"1.0-1", "1.0-2", -1},
+		{"1.0-1ubuntu1", "1.0-1", 1},
+		{"1.0.10", "1.0.9", 1},
+		{"1.2.3-1~bpo8+1", "1.2.3-1", -1},
+	})
+}
+
+func TestCompareRPM(t *testing.T) {
+	// Vectors from rpm's rpmvercmp.at.
+	testCompare(t, "CompareRPM", normalizedsort.CompareRPM, []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "2.0", -1},
+		{"2.0.1", "2.0.1a", -1},
+		{"5.5p1", "5.5p2", -1},
+		{"5.5p10", "5.5p1", 1},
+		{"10xyz", "10.1xyz", -1},
+		{"xyz10", "xyz10.1", -1},
+		{"xyz.4", "8", -1},
+		{"1b.fc17", "1b.fc17", 0},
+		{"1b.fc17", "1.fc17", -1},
+		{"1g.fc17", "1.fc17", 1},
+		{"1.1.α", "1.1.α", 0},
+		{"2.0", "2_0", 0},
+		{"2.0", "2.0.", 0},
+		{"2a", "2.0", -1},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0~rc1", "1.0~rc2", -1},
+		{"1.0~rc1~git123", "1.0~rc1", -1},
+		{"1.0^", "1.0", 1},
+		{"1.0^git1", "1.0", 1},
+		{"1.0^git1", "1.0^git2", -1},
+		{"1.0^git1", "1.01", -1},
+		{"1.0^20160101", "1.0.1", -1},
+		{"1.0~rc1^git1", "1.0~rc1", 1},
+		{"1.0^git1~pre", "1.0^git1", -1},
+		{"1:1.0", "2.0", 1},
+		{"1.0-1", "1.0-2", -1},
+	})
+}
@@ -0,0 +1,135 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// A Group runs functions in goroutines, no more than a limit at a time,
+// and collects their errors. A Group must be created with NewGroup and
+// must not be reused after Wait.
+type Group struct {
+	sem    *AtomicSemaphore
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+
+	mu            sync.Mutex
+	errs          []error
+	skipped       bool
+	cancelOnError bool
+}
+
+// NewGroup returns a Group that runs at most n functions at once. The
+// functions are passed a context derived from ctx that is canceled when
+// Wait returns.
+func NewGroup(ctx context.Context, n int) *Group {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{
+		sem:    NewAtomic(n),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// SetCancelOnError sets whether the first function to return an error
+// cancels the context of the Group, so that running functions can stop
+// early and waiting ones are not started.
+func (g *Group) SetCancelOnError(cancel bool) {
+	g.mu.Lock()
+	g.cancelOnError = cancel
+	g.mu.Unlock()
+}
+
+// Go waits for one of the Group's slots to be free and then calls f in a
+// new goroutine. If the Group's context is done first, f is not called,
+// and Wait reports the context's cause. A panic in f is recovered and
+// reported by Wait as a *PanicError.
+func (g *Group) Go(f func(ctx context.Context) error) {
+	err := g.sem.AcquireContext(g.ctx)
+	if err == nil && g.ctx.Err() != nil {
+		// A slot was free, but the context was canceled meanwhile.
+		g.sem.Release()
+		err = g.ctx.Err()
+	}
+	if err != nil {
+		g.mu.Lock()
+		g.skipped = true
+		g.mu.Unlock()
+		return
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer g.sem.Release()
+		if err := run(g.ctx, f); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+func run(ctx context.Context, f func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{r, debug.Stack()}
+		}
+	}()
+	return f(ctx)
+}
+
+func (g *Group) fail(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.errs = append(g.errs, err)
+	if g.cancelOnError {
+		g.cancel(err)
+	}
+}
+
+// Wait blocks until all functions started by Go have returned, then
+// returns their errors joined with errors.Join, or nil if there were none.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	errs := g.errs
+	if g.skipped {
+		if cause := context.Cause(g.ctx); !containsErr(errs, cause) {
+			errs = append(errs, cause)
+		}
+	}
+	g.cancel(context.Canceled)
+	return errors.Join(errs...)
+}
+
+func containsErr(errs []error, target error) bool {
+	for _, err := range errs {
+		if err == target {
+			return true
+		}
+	}
+	return false
+}
+
+// A PanicError reports a panic in a function run by a Group.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("semaphore: panic in Group function: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns Value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
@@ -0,0 +1,31 @@
+package semaphore
+
+import "context"
+
+// Interface is the behavior shared by the semaphore types of this
+// package. Package semaphoretest tests implementations of it.
+type Interface interface {
+	// Acquire blocks until it acquires a token, returning true, or the
+	// semaphore is stopped, returning false.
+	Acquire() bool
+	// AcquireContext is like Acquire, but gives up when ctx is done. It
+	// returns nil, ErrStopped, or ctx.Err().
+	AcquireContext(ctx context.Context) error
+	// TryAcquire acquires a token without blocking if one is available.
+	TryAcquire() bool
+	// Release returns a token. It is safe to call after Stop.
+	Release()
+	// Stop wakes all blocked callers of Acquire and makes further calls
+	// fail. It is safe to call multiple times.
+	Stop()
+	// Poll reports whether the semaphore has not been stopped.
+	Poll() bool
+	// Done returns a channel that is closed by Stop.
+	Done() <-chan struct{}
+}
+
+var (
+	_ Interface = (*Semaphore)(nil)
+	_ Interface = (*LockingSemaphore)(nil)
+	_ Interface = (*AtomicSemaphore)(nil)
+)
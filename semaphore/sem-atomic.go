@@ -0,0 +1,231 @@
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// AtomicSemaphore implements Interface, plus Wait, like Semaphore, but
+// acquires and releases uncontended tokens with atomic operations alone.
+// Only callers that must wait take a mutex, to park in a FIFO queue. It
+// does not support the other methods of Semaphore, such as AcquireN,
+// SetLimit, or Shutdown.
+type AtomicSemaphore struct {
+	max int64
+	// avail is the number of tokens that may be acquired.
+	avail atomic.Int64
+	// parked is the length of waiters, readable without mu. While it is
+	// nonzero, tokens are only handed out in order under mu.
+	parked  atomic.Int64
+	stopped atomic.Bool
+
+	mu sync.Mutex
+	// waiters holds the ready channel of each parked caller, in order.
+	waiters list.List
+	done    chan struct{}
+	once    sync.Once
+	// drained is closed once the AtomicSemaphore is stopped and all
+	// tokens have been released.
+	drained   chan struct{}
+	drainOnce sync.Once
+}
+
+// NewAtomic creates a new AtomicSemaphore with n max number of tokens
+// allowed.
+func NewAtomic(n int) *AtomicSemaphore {
+	s := &AtomicSemaphore{
+		max:     int64(n),
+		done:    make(chan struct{}),
+		drained: make(chan struct{}),
+	}
+	s.avail.Store(int64(n))
+	return s
+}
+
+// Acquire returns true after it acquires a token from the underlying
+// AtomicSemaphore or false if the AtomicSemaphore has been closed with
+// Stop().
+func (s *AtomicSemaphore) Acquire() bool {
+	return s.acquire(context.Background()) == nil
+}
+
+// AcquireContext acquires a token from the underlying AtomicSemaphore,
+// blocking until one is available, the AtomicSemaphore is closed with
+// Stop(), or ctx is done. It returns nil on success, ErrStopped if the
+// AtomicSemaphore is closed, or ctx.Err(). If ctx is already done,
+// AcquireContext does not acquire a token.
+func (s *AtomicSemaphore) AcquireContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.acquire(ctx)
+}
+
+// TryAcquire acquires a token from the underlying AtomicSemaphore if one
+// is available without waiting, and reports whether it did. It returns
+// false if the AtomicSemaphore has been closed with Stop().
+func (s *AtomicSemaphore) TryAcquire() bool {
+	if s.stopped.Load() {
+		return false
+	}
+	return s.tryFast() && s.keep()
+}
+
+// keep reports whether a token just taken may be kept, giving it back if
+// Stop was called meanwhile, so that no token is acquired after Wait
+// may have returned.
+func (s *AtomicSemaphore) keep() bool {
+	if !s.stopped.Load() {
+		return true
+	}
+	s.Release()
+	return false
+}
+
+// tryFast takes a token if one is free and no one is parked.
+func (s *AtomicSemaphore) tryFast() bool {
+	for {
+		a := s.avail.Load()
+		if a <= 0 || s.parked.Load() > 0 {
+			return false
+		}
+		if s.avail.CompareAndSwap(a, a-1) {
+			return true
+		}
+	}
+}
+
+func (s *AtomicSemaphore) acquire(ctx context.Context) error {
+	if s.stopped.Load() {
+		return ErrStopped
+	}
+	if s.tryFast() {
+		if !s.keep() {
+			return ErrStopped
+		}
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.stopped.Load() {
+		s.mu.Unlock()
+		return ErrStopped
+	}
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(ready)
+	s.parked.Add(1)
+	// A Release that saw no one parked may have freed a token since
+	// tryFast, so check again now that Release will see this caller.
+	s.dispatch()
+	s.mu.Unlock()
+
+	var err error
+	select {
+	case <-ready:
+		return nil
+	case <-s.done:
+		err = ErrStopped
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-ready:
+		// Acquired as the wait ended; give the token back.
+		s.avail.Add(1)
+		s.checkDrained()
+	default:
+		s.waiters.Remove(elem)
+		s.parked.Add(-1)
+	}
+	s.dispatch()
+	return err
+}
+
+// dispatch hands free tokens to parked callers in order. s.mu must be
+// held. Once stopped, parked callers are woken by Stop instead.
+func (s *AtomicSemaphore) dispatch() {
+	for s.waiters.Len() > 0 && !s.stopped.Load() {
+		a := s.avail.Load()
+		if a <= 0 {
+			return
+		}
+		if !s.avail.CompareAndSwap(a, a-1) {
+			continue
+		}
+		front := s.waiters.Front()
+		s.waiters.Remove(front)
+		s.parked.Add(-1)
+		close(front.Value.(chan struct{}))
+	}
+}
+
+// Release returns an AtomicSemaphore token. It is safe to call after the
+// AtomicSemaphore has been closed with Stop(). Releasing a token that was
+// not acquired has no effect.
+func (s *AtomicSemaphore) Release() {
+	for {
+		a := s.avail.Load()
+		if a >= s.max {
+			return
+		}
+		if s.avail.CompareAndSwap(a, a+1) {
+			break
+		}
+	}
+	if s.parked.Load() > 0 {
+		s.mu.Lock()
+		s.dispatch()
+		s.mu.Unlock()
+	}
+	s.checkDrained()
+}
+
+// checkDrained closes drained if the AtomicSemaphore has been stopped and
+// all tokens have been released.
+func (s *AtomicSemaphore) checkDrained() {
+	if s.stopped.Load() && s.avail.Load() >= s.max {
+		s.drainOnce.Do(func() { close(s.drained) })
+	}
+}
+
+// Stop closes its underlying AtomicSemaphore. It is safe to call multiple
+// times.
+func (s *AtomicSemaphore) Stop() {
+	s.once.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.stopped.Store(true)
+		close(s.done)
+	})
+	s.checkDrained()
+}
+
+// Wait blocks until the AtomicSemaphore has been closed with Stop() and
+// all acquired tokens have been released.
+func (s *AtomicSemaphore) Wait() {
+	<-s.drained
+}
+
+// Poll reports whether the underlying AtomicSemaphore is open. For
+// practical purposes, this is only useful for routines that are already
+// holding a token from Acquire() if they want to decide to continue
+// working on an expensive operation.
+func (s *AtomicSemaphore) Poll() bool {
+	return !s.stopped.Load()
+}
+
+// Done returns a channel that is closed when the AtomicSemaphore is closed
+// with Stop(), for use in select statements.
+func (s *AtomicSemaphore) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *AtomicSemaphore) String() string {
+	return fmt.Sprintf("AtomicSemaphore{ n: %d, used: %d }", s.max, s.max-s.avail.Load())
+}
@@ -1,28 +1,60 @@
 package semaphore
 
 import (
+	"container/list"
+	"context"
 	"fmt"
 	"sync"
+	"time"
 )
 
 // LockingSemaphore is functionally identical to Semaphore, but uses
 // mutexes internally.
 type LockingSemaphore struct {
-	sem  chan struct{}
-	done chan struct{}
-	// rw ensures that no calls to Release after a call to Stop will
-	// result in a token being returned (and thereby acquired by a
-	// previously blocked call to Aquire).
-	rw sync.RWMutex
+	mu         sync.Mutex
+	max, count int
+	strict     bool
+	// waiters holds the *waiter of each blocked acquisition, in order.
+	waiters list.List
+	done    chan struct{}
+	cause   error
+	// drained is closed once the LockingSemaphore is stopped and all
+	// tokens have been released.
+	drained chan struct{}
+	tracker tracker
 	// once ensures that done is not closed twice.
 	once sync.Once
+	// pump feeds acquireCh for AcquireChan, once started.
+	pump      sync.Once
+	acquireCh chan bool
+	// offer is the token the pump is offering on acquireCh, if any.
+	offer *offer
+}
+
+// A waiter's ready channel is closed when its n tokens have been acquired
+// or, if err is set, when they cannot be. The pump of AcquireChan waits
+// for its token with an offer to make once it is granted.
+type waiter struct {
+	n     int
+	err   error
+	ready chan struct{}
+	offer *offer
+}
+
+// An offer is a token held by the pump of AcquireChan until a receiver
+// takes it. Other callers take it back by closing revoke, and learn from
+// taken, once end is closed, whether the token passed to them.
+type offer struct {
+	claimed, taken bool
+	revoke, end    chan struct{}
 }
 
 // NewLS creates a new LockingSemaphore with n max number of tokens allowed.
 func NewLS(n int) *LockingSemaphore {
 	return &LockingSemaphore{
-		sem:  make(chan struct{}, n),
-		done: make(chan struct{}),
+		max:     n,
+		done:    make(chan struct{}),
+		drained: make(chan struct{}),
 	}
 }
 
@@ -30,43 +62,442 @@ func NewLS(n int) *LockingSemaphore {
 // LockingSemaphore or false if the LockingSemaphore has been closed
 // with Stop().
 func (s *LockingSemaphore) Acquire() bool {
-	s.rw.RLock()
-	sem := s.sem
-	s.rw.RUnlock()
+	return s.acquire(context.Background(), 1) == nil
+}
+
+// AcquireContext acquires a token from the underlying LockingSemaphore,
+// blocking until one is available, the LockingSemaphore is closed with
+// Stop(), or ctx is done. It returns nil on success, ErrStopped if the
+// LockingSemaphore is closed, or ctx.Err(). If ctx is already done,
+// AcquireContext does not acquire a token.
+func (s *LockingSemaphore) AcquireContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.acquire(ctx, 1)
+}
+
+// AcquireN acquires n tokens from the underlying LockingSemaphore at once,
+// blocking until they are available. All acquisitions that must wait,
+// whether by AcquireN, Acquire, or AcquireContext, are served in a single
+// queue in order, so a large request waits for earlier ones but is not
+// overtaken by later ones. It returns nil on success, ErrStopped if the
+// LockingSemaphore has been closed with Stop(), or an error wrapping
+// ErrTooMany if n exceeds the maximum number of tokens.
+func (s *LockingSemaphore) AcquireN(n int) error {
+	if n < 0 {
+		panic("semaphore: negative token count")
+	}
+	return s.acquire(context.Background(), n)
+}
+
+// TryAcquire acquires a token from the underlying LockingSemaphore if one
+// is available without waiting, and reports whether it did. It returns
+// false if the LockingSemaphore has been closed with Stop().
+func (s *LockingSemaphore) TryAcquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		select {
+		case <-s.done:
+			return false
+		default:
+		}
+		if s.waiters.Len() == 0 && s.count < s.max {
+			s.count++
+			return true
+		}
+		if s.offer == nil {
+			return false
+		}
+		if s.takeOffer() {
+			return true
+		}
+	}
+}
+
+// AcquireTimeout is like Acquire, but gives up and returns false if no
+// token becomes available within d.
+func (s *LockingSemaphore) AcquireTimeout(d time.Duration) bool {
+	if d <= 0 {
+		return s.TryAcquire()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return s.acquire(ctx, 1) == nil
+}
+
+func (s *LockingSemaphore) acquire(ctx context.Context, n int) error {
+	s.mu.Lock()
+	for {
+		select {
+		case <-s.done:
+			s.mu.Unlock()
+			return ErrStopped
+		default:
+		}
+		if n > s.max {
+			s.mu.Unlock()
+			return tooMany(n, s.max)
+		}
+		if s.waiters.Len() == 0 && s.count+n <= s.max {
+			s.count += n
+			s.mu.Unlock()
+			return nil
+		}
+		if s.offer == nil {
+			break
+		}
+		if s.takeOffer() {
+			if s.count+n-1 <= s.max {
+				s.count += n - 1
+				s.mu.Unlock()
+				return nil
+			}
+			// Too few tokens even with the offered one; wait in line.
+			s.release(1)
+			break
+		}
+	}
+	w := &waiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
 
 	select {
-	case sem <- struct{}{}:
-		return true
+	case <-w.ready:
+		return w.err
 	case <-s.done:
-		return false
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-w.ready:
+			// Acquired just before Stop, so the caller must not release.
+			if w.err == nil {
+				s.release(n)
+			}
+		default:
+			s.waiters.Remove(elem)
+		}
+		return ErrStopped
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-w.ready:
+			// Acquired after ctx was done; give the tokens back.
+			if w.err == nil {
+				s.release(n)
+			}
+		default:
+			s.waiters.Remove(elem)
+			// A large waiter leaving the front may unblock smaller ones.
+			s.release(0)
+		}
+		return ctx.Err()
+	}
+}
+
+// takeOffer takes back the token offered on AcquireChan, so that it is
+// not held in reserve while other callers wait, and reports whether the
+// token passed to the caller instead of a receiver. s.mu must be held and
+// s.offer set; s.mu is released while the pump answers.
+func (s *LockingSemaphore) takeOffer() bool {
+	o := s.offer
+	mine := !o.claimed
+	if mine {
+		o.claimed = true
+		close(o.revoke)
+	}
+	s.mu.Unlock()
+	<-o.end
+	s.mu.Lock()
+	return mine && o.taken
+}
+
+// held returns the number of tokens held by callers. s.mu must be held.
+func (s *LockingSemaphore) held() int {
+	if s.offer != nil {
+		return s.count - 1
+	}
+	return s.count
+}
+
+// notify hands tokens to waiters in order while they fit. s.mu must be held.
+func (s *LockingSemaphore) notify() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*waiter)
+		if w.offer != nil && s.waiters.Len() > 1 {
+			// The pump of AcquireChan lets other waiters go first.
+			s.waiters.MoveToBack(front)
+			continue
+		}
+		if s.count+w.n > s.max {
+			return
+		}
+		s.count += w.n
+		s.waiters.Remove(front)
+		if w.offer != nil {
+			s.offer = w.offer
+		}
+		close(w.ready)
 	}
 }
 
 // Release returns a LockingSemaphore token. It is safe to call after
-// the LockingSemaphore has been closed with Stop().
+// the LockingSemaphore has been closed with Stop(). Releasing a token that
+// was not acquired has no effect, unless the LockingSemaphore is strict.
 func (s *LockingSemaphore) Release() {
-	s.rw.RLock()
-	sem := s.sem
-	s.rw.RUnlock()
+	s.ReleaseN(1)
+}
+
+// ReleaseN returns n LockingSemaphore tokens, as if by n calls to Release.
+func (s *LockingSemaphore) ReleaseN(n int) {
+	if n < 0 {
+		panic("semaphore: negative token count")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.releaseHeld(n) && s.strict {
+		panic(ErrOverRelease)
+	}
+}
+
+// CheckedRelease is like ReleaseN, but returns ErrOverRelease instead of
+// panicking if more tokens are released than are held, whether or not the
+// LockingSemaphore is strict. The tokens that were held are still
+// released.
+func (s *LockingSemaphore) CheckedRelease(n int) error {
+	if n < 0 {
+		panic("semaphore: negative token count")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.releaseHeld(n) {
+		return ErrOverRelease
+	}
+	return nil
+}
+
+// releaseHeld releases up to n held tokens and reports whether n were
+// held. s.mu must be held.
+func (s *LockingSemaphore) releaseHeld(n int) bool {
+	held := s.held()
+	if n > held {
+		s.release(held)
+		return false
+	}
+	s.release(n)
+	return true
+}
 
+// release returns n tokens. s.mu must be held. Once stopped, no tokens may
+// be handed to calls to Acquire that were blocked before Stop.
+func (s *LockingSemaphore) release(n int) {
+	s.count -= n
 	select {
-	case <-sem:
 	case <-s.done:
+		s.checkDrained()
+	default:
+		s.notify()
+	}
+}
+
+// checkDrained closes drained if all tokens have been released after
+// Stop. s.mu must be held and s.done closed.
+func (s *LockingSemaphore) checkDrained() {
+	if s.count > 0 {
+		return
+	}
+	select {
+	case <-s.drained:
+	default:
+		close(s.drained)
 	}
 }
 
+// SetStrict sets whether the LockingSemaphore panics with ErrOverRelease
+// when more tokens are released than are held. Otherwise such releases
+// are ignored, so that they cannot raise the effective limit.
+func (s *LockingSemaphore) SetStrict(strict bool) {
+	s.mu.Lock()
+	s.strict = strict
+	s.mu.Unlock()
+}
+
+// SetLimit changes the maximum number of tokens of the underlying
+// LockingSemaphore to n. Raising the limit hands tokens to waiting callers
+// at once. Lowering it does not revoke tokens already acquired: no new
+// tokens are handed out until enough are released to fall below the new
+// limit. Pending calls to AcquireN for more than n tokens fail with
+// ErrTooMany. SetLimit has no effect after Stop().
+func (s *LockingSemaphore) SetLimit(n int) {
+	if n < 0 {
+		panic("semaphore: negative limit")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	s.max = n
+	for e := s.waiters.Front(); e != nil; {
+		next := e.Next()
+		if w := e.Value.(*waiter); w.n > n {
+			w.err = tooMany(w.n, n)
+			s.waiters.Remove(e)
+			close(w.ready)
+		}
+		e = next
+	}
+	s.notify()
+}
+
 // Stop closes its underlying LockingSemaphore. It is safe to call multiple
 // times.
 func (s *LockingSemaphore) Stop() {
+	s.StopWithCause(nil)
+}
+
+// StopWithCause is like Stop, but records err as the reason, to be
+// reported by Err. If err is nil, the cause is ErrStopped. Only the cause
+// of the first call to Stop or StopWithCause is kept.
+func (s *LockingSemaphore) StopWithCause(err error) {
+	if err == nil {
+		err = ErrStopped
+	}
 	s.once.Do(func() {
-		s.rw.Lock()
-		defer s.rw.Unlock()
+		s.mu.Lock()
+		defer s.mu.Unlock()
 
-		s.sem = nil
+		s.cause = err
 		close(s.done)
+		s.checkDrained()
 	})
 }
 
+// Wait blocks until the LockingSemaphore has been closed with Stop() and
+// all acquired tokens have been released.
+func (s *LockingSemaphore) Wait() {
+	<-s.drained
+}
+
+// Err returns nil while the LockingSemaphore is open, and the cause given
+// to StopWithCause, or ErrStopped, once it has been closed.
+func (s *LockingSemaphore) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cause
+}
+
+// Done returns a channel that is closed when the LockingSemaphore is
+// closed with Stop(), for use in select statements.
+func (s *LockingSemaphore) Done() <-chan struct{} {
+	return s.done
+}
+
+// AcquireChan returns a channel that yields true each time a token is
+// acquired from the LockingSemaphore by receiving from it, so that
+// acquisition can be combined with other events in a select statement.
+// Once the LockingSemaphore has been closed with Stop(), the channel is
+// closed and yields false. A token is only acquired by a receive that
+// succeeds with true.
+//
+// The first call starts a goroutine that acquires a token ahead of each
+// receive and offers it on the channel. Other callers take the offered
+// token back when they would otherwise have to wait, so it is not held
+// in reserve, and it is not counted as held by String or Shutdown.
+func (s *LockingSemaphore) AcquireChan() <-chan bool {
+	s.pump.Do(func() {
+		s.acquireCh = make(chan bool)
+		go s.pumpTokens()
+	})
+	return s.acquireCh
+}
+
+func (s *LockingSemaphore) pumpTokens() {
+	defer close(s.acquireCh)
+	for {
+		o := s.acquireOffer()
+		if o == nil {
+			return
+		}
+		stopped := false
+		select {
+		case s.acquireCh <- true:
+		case <-o.revoke:
+			o.taken = true
+		case <-s.done:
+			stopped = true
+		}
+		s.mu.Lock()
+		s.offer = nil
+		if stopped {
+			s.release(1)
+		}
+		s.mu.Unlock()
+		close(o.end)
+		if stopped {
+			return
+		}
+	}
+}
+
+// acquireOffer acquires a token for the pump of AcquireChan and sets it as
+// s.offer in the same critical section, so that other callers never see
+// it held without being able to take it back. It returns nil once the
+// pump should stop.
+func (s *LockingSemaphore) acquireOffer() *offer {
+	o := &offer{revoke: make(chan struct{}), end: make(chan struct{})}
+	s.mu.Lock()
+	select {
+	case <-s.done:
+		s.mu.Unlock()
+		return nil
+	default:
+	}
+	if s.waiters.Len() == 0 && s.count < s.max {
+		s.count++
+		s.offer = o
+		s.mu.Unlock()
+		return o
+	}
+	w := &waiter{n: 1, ready: make(chan struct{}), offer: o}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		if w.err != nil {
+			return nil
+		}
+		return o
+	case <-s.done:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-w.ready:
+			if w.err == nil {
+				s.offer = nil
+				s.release(1)
+			}
+		default:
+			s.waiters.Remove(elem)
+		}
+		return nil
+	}
+}
+
 // Poll reports whether the underlying LockingSemaphore is open. For practical
 // purposes, this is only useful for routines that are already holding
 // a token from Acquire() if they want to decide to continue working on
@@ -81,9 +512,10 @@ func (s *LockingSemaphore) Poll() bool {
 }
 
 func (s *LockingSemaphore) String() string {
-	s.rw.RLock()
-	capacity, length := cap(s.sem), len(s.sem)
-	s.rw.RUnlock()
+	s.mu.Lock()
+	capacity, length := s.max, s.held()
+	s.mu.Unlock()
 
-	return fmt.Sprintf("LockingSemaphore{ n: %d, used: %d }", capacity, length)
+	return fmt.Sprintf("LockingSemaphore{ n: %d, effective: %d, used: %d }",
+		capacity, effectiveLimit(capacity, length), length)
 }
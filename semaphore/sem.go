@@ -4,7 +4,31 @@
 // copy it and make your own specialized implementation.
 package semaphore
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrStopped is returned by AcquireContext and AcquireN when the
+	// semaphore has been closed with Stop().
+	ErrStopped = errors.New("semaphore: stopped")
+	// ErrTooMany is returned by AcquireN when more tokens are requested
+	// than the semaphore allows at once.
+	ErrTooMany = errors.New("semaphore: request exceeds capacity")
+	// ErrOverRelease is the panic value of a strict semaphore when more
+	// tokens are released than are held, and is returned by
+	// CheckedRelease in that case.
+	ErrOverRelease = errors.New("semaphore: released more tokens than acquired")
+)
+
+func tooMany(n, max int) error {
+	return fmt.Errorf("%w: %d > %d", ErrTooMany, n, max)
+}
 
 // A Semaphore helps add restrictions on the number of active goroutines
 // by ensuring that no more than n tokens may be acquired at one time.
@@ -12,11 +36,42 @@ import "fmt"
 type Semaphore struct {
 	// use chan bool to simplify testing if channel is closed
 	acquire  chan bool
-	release  chan struct{}
+	acquireN chan request
+	releaseN chan release
+	// released acknowledges each release that asks for it, reporting
+	// false if more tokens were released than were held.
+	released chan bool
 	stop     chan struct{}
 	wait     chan struct{}
 	poll     chan struct{}
+	setLimit chan int
 	stringer chan stringerData
+	// held reports the tokens still held while draining after Stop.
+	held    chan int
+	strict  atomic.Bool
+	tracker tracker
+	// cause is set once by the first call to Stop or StopWithCause,
+	// before the server closes poll.
+	cause     error
+	causeOnce sync.Once
+}
+
+// A request asks the server for n tokens. The server replies with nil once
+// they are acquired, or with an error. If try is set, the server replies
+// with errWouldBlock instead of queueing the request.
+type request struct {
+	n     int
+	try   bool
+	reply chan error
+}
+
+var errWouldBlock = errors.New("semaphore: would block")
+
+// A release returns n tokens to the server. If ack is set, the server
+// reports on released whether they were held.
+type release struct {
+	n   int
+	ack bool
 }
 
 type stringerData struct {
@@ -27,11 +82,15 @@ type stringerData struct {
 func New(n int) *Semaphore {
 	s := Semaphore{
 		acquire:  make(chan bool),
-		release:  make(chan struct{}),
+		acquireN: make(chan request),
+		releaseN: make(chan release),
+		released: make(chan bool),
 		stop:     make(chan struct{}),
 		wait:     make(chan struct{}),
 		poll:     make(chan struct{}),
+		setLimit: make(chan int),
 		stringer: make(chan stringerData),
+		held:     make(chan int),
 	}
 	go s.start(n)
 	return &s
@@ -39,20 +98,44 @@ func New(n int) *Semaphore {
 
 func (s *Semaphore) start(max int) {
 	count := 0
+	// queue holds the requests of AcquireN waiting for tokens, in order.
+	var queue []request
 
 	for {
 		var acquire = s.acquire
 
-		// nil always blocks sends
-		if count >= max {
+		// nil always blocks sends. Single tokens are not handed out while
+		// requests are queued, so that large requests are not starved.
+		if count >= max || len(queue) > 0 {
 			acquire = nil
 		}
 
 		select {
 		case acquire <- true:
 			count++
-		case s.release <- struct{}{}:
-			count--
+		case r := <-s.acquireN:
+			switch {
+			case r.n > max:
+				r.reply <- tooMany(r.n, max)
+			case r.try && (len(queue) > 0 || count+r.n > max):
+				r.reply <- errWouldBlock
+			default:
+				queue = append(queue, r)
+			}
+		case r := <-s.releaseN:
+			count = s.ack(count, r)
+		case max = <-s.setLimit:
+			// Requests that can no longer be satisfied fail rather than
+			// wait forever.
+			kept := queue[:0]
+			for _, r := range queue {
+				if r.n > max {
+					r.reply <- tooMany(r.n, max)
+				} else {
+					kept = append(kept, r)
+				}
+			}
+			queue = kept
 		case s.stringer <- stringerData{max, count}:
 		case s.stop <- struct{}{}:
 			close(s.acquire)
@@ -60,15 +143,41 @@ func (s *Semaphore) start(max int) {
 			close(s.poll)
 			close(s.stop)
 
+			for _, r := range queue {
+				r.reply <- ErrStopped
+			}
+
 			for count > 0 {
-				s.release <- struct{}{}
-				count--
+				select {
+				case r := <-s.releaseN:
+					count = s.ack(count, r)
+				case s.held <- count:
+				}
 			}
 
 			close(s.wait)
 			return
 		}
+
+		for len(queue) > 0 && count+queue[0].n <= max {
+			count += queue[0].n
+			queue[0].reply <- nil
+			queue = queue[1:]
+		}
+	}
+}
+
+// ack applies r to count held tokens, acknowledging it if asked, and
+// returns the number still held. Releasing more than are held releases
+// them all.
+func (s *Semaphore) ack(count int, r release) int {
+	if r.ack {
+		s.released <- r.n <= count
+	}
+	if r.n > count {
+		return 0
 	}
+	return count - r.n
 }
 
 // Acquire returns true after it acquires a token from the underlying
@@ -77,18 +186,190 @@ func (s *Semaphore) Acquire() bool {
 	return <-s.acquire
 }
 
+// AcquireContext acquires a token from the underlying Semaphore,
+// blocking until one is available, the Semaphore is closed with Stop(),
+// or ctx is done. It returns nil on success, ErrStopped if the Semaphore
+// is closed, or ctx.Err(). If ctx is already done, AcquireContext does
+// not acquire a token.
+func (s *Semaphore) AcquireContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case ok := <-s.acquire:
+		if !ok {
+			return ErrStopped
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Release returns a Semaphore token. It is safe to call after the
-// Semaphore has been closed with Stop().
+// Semaphore has been closed with Stop(). Releasing a token that was not
+// acquired has no effect, unless the Semaphore is strict.
 func (s *Semaphore) Release() {
-	<-s.release
+	s.ReleaseN(1)
+}
+
+// AcquireN acquires n tokens from the underlying Semaphore at once,
+// blocking until they are available. Calls to AcquireN are served in
+// order, and while any is waiting, no tokens are handed to Acquire, so a
+// large request is not starved by a stream of smaller ones. Calls to
+// Acquire are not queued, however: one that is already blocked may be
+// overtaken by a later AcquireN. LockingSemaphore serves both in a single
+// queue. It returns nil on success, ErrStopped if the Semaphore has been
+// closed with Stop(), or an error wrapping ErrTooMany if n exceeds the
+// maximum number of tokens.
+func (s *Semaphore) AcquireN(n int) error {
+	if n < 0 {
+		panic("semaphore: negative token count")
+	}
+	return s.request(request{n: n, reply: make(chan error, 1)})
+}
+
+func (s *Semaphore) request(r request) error {
+	select {
+	case s.acquireN <- r:
+		return <-r.reply
+	case <-s.poll:
+		return ErrStopped
+	}
+}
+
+// TryAcquire acquires a token from the underlying Semaphore if one is
+// available without waiting, and reports whether it did. It returns false
+// if the Semaphore has been closed with Stop().
+func (s *Semaphore) TryAcquire() bool {
+	return s.request(request{n: 1, try: true, reply: make(chan error, 1)}) == nil
+}
+
+// AcquireTimeout is like Acquire, but gives up and returns false if no
+// token becomes available within d.
+func (s *Semaphore) AcquireTimeout(d time.Duration) bool {
+	if d <= 0 {
+		return s.TryAcquire()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case ok := <-s.acquire:
+		return ok
+	case <-t.C:
+		return false
+	}
+}
+
+// ReleaseN returns n Semaphore tokens, as if by n calls to Release.
+func (s *Semaphore) ReleaseN(n int) {
+	if n < 0 {
+		panic("semaphore: negative token count")
+	}
+	if n == 0 {
+		return
+	}
+	// Only a strict Semaphore waits to learn whether the tokens were held.
+	if strict := s.strict.Load(); !s.release(n, strict) && strict {
+		panic(ErrOverRelease)
+	}
+}
+
+// CheckedRelease is like ReleaseN, but returns ErrOverRelease instead of
+// panicking if more tokens are released than are held, whether or not the
+// Semaphore is strict. The tokens that were held are still released.
+func (s *Semaphore) CheckedRelease(n int) error {
+	if n < 0 {
+		panic("semaphore: negative token count")
+	}
+	if n > 0 && !s.release(n, true) {
+		return ErrOverRelease
+	}
+	return nil
+}
+
+// release returns n tokens to the server. If ack is set, it reports
+// whether they were held; otherwise it reports true unless the Semaphore
+// has been stopped with every token released.
+func (s *Semaphore) release(n int, ack bool) bool {
+	select {
+	case s.releaseN <- release{n, ack}:
+		if ack {
+			return <-s.released
+		}
+		return true
+	case <-s.wait:
+		// Stopped with every token released.
+		return false
+	}
+}
+
+// SetStrict sets whether the Semaphore panics with ErrOverRelease when
+// more tokens are released than are held. Otherwise such releases are
+// ignored, so that they cannot raise the effective limit.
+func (s *Semaphore) SetStrict(strict bool) {
+	s.strict.Store(strict)
+}
+
+// SetLimit changes the maximum number of tokens of the underlying
+// Semaphore to n. Raising the limit hands tokens to waiting callers at
+// once. Lowering it does not revoke tokens already acquired: no new tokens
+// are handed out until enough are released to fall below the new limit.
+// Pending calls to AcquireN for more than n tokens fail with ErrTooMany.
+// SetLimit has no effect after Stop().
+func (s *Semaphore) SetLimit(n int) {
+	if n < 0 {
+		panic("semaphore: negative limit")
+	}
+	select {
+	case s.setLimit <- n:
+	case <-s.poll:
+	}
 }
 
 // Stop closes its underlying Semaphore. It is safe to call multiple
 // times.
 func (s *Semaphore) Stop() {
+	s.StopWithCause(nil)
+}
+
+// StopWithCause is like Stop, but records err as the reason, to be
+// reported by Err. If err is nil, the cause is ErrStopped. Only the cause
+// of the first call to Stop or StopWithCause is kept.
+func (s *Semaphore) StopWithCause(err error) {
+	if err == nil {
+		err = ErrStopped
+	}
+	s.causeOnce.Do(func() { s.cause = err })
 	<-s.stop
 }
 
+// Err returns nil while the Semaphore is open, and the cause given to
+// StopWithCause, or ErrStopped, once it has been closed.
+func (s *Semaphore) Err() error {
+	select {
+	case <-s.poll:
+		return s.cause
+	default:
+		return nil
+	}
+}
+
+// Done returns a channel that is closed when the Semaphore is closed with
+// Stop(), for use in select statements.
+func (s *Semaphore) Done() <-chan struct{} {
+	return s.poll
+}
+
+// AcquireChan returns a channel that yields true each time a token is
+// acquired from the Semaphore by receiving from it, so that acquisition
+// can be combined with other events in a select statement. Once the
+// Semaphore has been closed with Stop(), the channel is closed and yields
+// false. A token is only acquired by a receive that succeeds with true.
+func (s *Semaphore) AcquireChan() <-chan bool {
+	return s.acquire
+}
+
 // Wait blocks until all acquired tokens have been released.
 func (s *Semaphore) Wait() {
 	<-s.wait
@@ -109,7 +390,17 @@ func (s *Semaphore) Poll() bool {
 
 func (s *Semaphore) String() string {
 	if v, ok := <-s.stringer; ok {
-		return fmt.Sprintf("Semaphore{ n: %d, used: %d }", v.max, v.count)
+		return fmt.Sprintf("Semaphore{ n: %d, effective: %d, used: %d }",
+			v.max, effectiveLimit(v.max, v.count), v.count)
 	}
 	return "Semaphore{closed}"
 }
+
+// effectiveLimit is the number of tokens that may be held at once while
+// holders drain down to a lowered limit.
+func effectiveLimit(max, count int) int {
+	if count > max {
+		return count
+	}
+	return max
+}
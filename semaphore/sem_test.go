@@ -1,11 +1,17 @@
 package semaphore_test
 
 import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/carlmjohnson/go-utils/semaphore"
+	"github.com/carlmjohnson/go-utils/semaphore/semaphoretest"
 )
 
 func BenchmarkSemAcq(b *testing.B) {
@@ -177,3 +183,747 @@ func BenchmarkLSemWait(b *testing.B) {
 		wg.Wait()
 	}
 }
+
+type sem interface {
+	semaphore.Interface
+	AcquireN(int) error
+	AcquireTimeout(time.Duration) bool
+	ReleaseN(int)
+	CheckedRelease(int) error
+	SetLimit(int)
+	SetStrict(bool)
+	AcquireToken() *semaphore.Token
+	StopWithCause(error)
+	Err() error
+	AcquireChan() <-chan bool
+	SetTracking(bool)
+	Shutdown(context.Context) error
+	Wait()
+	String() string
+}
+
+var implementations = map[string]func(int) sem{
+	"Semaphore":        func(n int) sem { return semaphore.New(n) },
+	"LockingSemaphore": func(n int) sem { return semaphore.NewLS(n) },
+}
+
+func TestConformance(t *testing.T) {
+	for name, newSem := range map[string]func(int) semaphore.Interface{
+		"Semaphore":        func(n int) semaphore.Interface { return semaphore.New(n) },
+		"LockingSemaphore": func(n int) semaphore.Interface { return semaphore.NewLS(n) },
+		"AtomicSemaphore":  func(n int) semaphore.Interface { return semaphore.NewAtomic(n) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			semaphoretest.Run(t, newSem)
+		})
+	}
+}
+
+type waiter interface {
+	semaphore.Interface
+	Wait()
+}
+
+func TestWait(t *testing.T) {
+	for name, newSem := range map[string]func(int) waiter{
+		"Semaphore":        func(n int) waiter { return semaphore.New(n) },
+		"LockingSemaphore": func(n int) waiter { return semaphore.NewLS(n) },
+		"AtomicSemaphore":  func(n int) waiter { return semaphore.NewAtomic(n) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(2)
+			s.Acquire()
+			s.Acquire()
+			s.Stop()
+			var released atomic.Bool
+			go func() {
+				time.Sleep(time.Millisecond)
+				released.Store(true)
+				s.Release()
+				s.Release()
+			}()
+			s.Wait()
+			if !released.Load() {
+				t.Error("Wait returned while tokens were held")
+			}
+		})
+	}
+}
+
+func TestAcquireContext(t *testing.T) {
+	for name, newSem := range implementations {
+		t.Run(name, func(t *testing.T) {
+			before := runtime.NumGoroutine()
+			s := newSem(1)
+			if err := s.AcquireContext(context.Background()); err != nil {
+				t.Fatalf("AcquireContext on free semaphore = %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			if err := s.AcquireContext(ctx); err != context.DeadlineExceeded {
+				t.Fatalf("AcquireContext on full semaphore = %v; want %v", err, context.DeadlineExceeded)
+			}
+
+			ctx, cancel = context.WithCancel(context.Background())
+			cancel()
+			s.Release()
+			if err := s.AcquireContext(ctx); err != context.Canceled {
+				t.Fatalf("AcquireContext with canceled context = %v; want %v", err, context.Canceled)
+			}
+			if !s.Acquire() {
+				t.Fatal("token was taken by canceled AcquireContext")
+			}
+
+			errc := make(chan error)
+			go func() { errc <- s.AcquireContext(context.Background()) }()
+			time.Sleep(time.Millisecond)
+			s.Stop()
+			if err := <-errc; err != semaphore.ErrStopped {
+				t.Fatalf("AcquireContext after Stop = %v; want %v", err, semaphore.ErrStopped)
+			}
+			s.Release()
+
+			for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+				time.Sleep(time.Millisecond)
+			}
+			if n := runtime.NumGoroutine(); n > before {
+				t.Errorf("%d goroutines leaked", n-before)
+			}
+		})
+	}
+}
+
+func TestAcquireN(t *testing.T) {
+	for name, newSem := range implementations {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(4)
+			if err := s.AcquireN(5); !errors.Is(err, semaphore.ErrTooMany) {
+				t.Fatalf("AcquireN(5) = %v; want ErrTooMany", err)
+			}
+			if err := s.AcquireN(3); err != nil {
+				t.Fatalf("AcquireN(3) = %v", err)
+			}
+
+			order := make(chan string, 2)
+			go func() {
+				if err := s.AcquireN(4); err != nil {
+					t.Errorf("AcquireN(4) = %v", err)
+				}
+				order <- "large"
+				s.ReleaseN(4)
+			}()
+			time.Sleep(5 * time.Millisecond)
+			go func() {
+				// A token is free, but the large request came first.
+				s.Acquire()
+				order <- "small"
+				s.Release()
+			}()
+			time.Sleep(5 * time.Millisecond)
+			if len(order) != 0 {
+				t.Fatalf("%s acquired before release", <-order)
+			}
+			s.ReleaseN(3)
+			if first, second := <-order, <-order; first != "large" || second != "small" {
+				t.Errorf("acquired in order %s, %s; want large, small", first, second)
+			}
+
+			s.AcquireN(4)
+			errc := make(chan error)
+			go func() { errc <- s.AcquireN(2) }()
+			time.Sleep(5 * time.Millisecond)
+			s.Stop()
+			if err := <-errc; err != semaphore.ErrStopped {
+				t.Errorf("queued AcquireN after Stop = %v; want ErrStopped", err)
+			}
+			if err := s.AcquireN(1); err != semaphore.ErrStopped {
+				t.Errorf("AcquireN after Stop = %v; want ErrStopped", err)
+			}
+			s.ReleaseN(4)
+		})
+	}
+}
+
+func TestAcquireNOvertakes(t *testing.T) {
+	for name, newSem := range implementations {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(1)
+			s.Acquire()
+			order := make(chan string, 2)
+			go func() {
+				s.Acquire()
+				order <- "Acquire"
+				s.Release()
+			}()
+			time.Sleep(5 * time.Millisecond)
+			go func() {
+				s.AcquireN(1)
+				order <- "AcquireN"
+				s.Release()
+			}()
+			time.Sleep(5 * time.Millisecond)
+			s.Release()
+
+			// Semaphore queues only AcquireN, which overtakes the earlier
+			// Acquire; LockingSemaphore queues both in order.
+			want := "Acquire"
+			if name == "Semaphore" {
+				want = "AcquireN"
+			}
+			if first := <-order; first != want {
+				t.Errorf("%s acquired first; want %s", first, want)
+			}
+			<-order
+		})
+	}
+}
+
+func TestTryAcquire(t *testing.T) {
+	for name, newSem := range implementations {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(1)
+			if !s.TryAcquire() {
+				t.Fatal("TryAcquire on free semaphore failed")
+			}
+			if s.TryAcquire() {
+				t.Fatal("TryAcquire on full semaphore succeeded")
+			}
+			start := time.Now()
+			if s.AcquireTimeout(10 * time.Millisecond) {
+				t.Fatal("AcquireTimeout on full semaphore succeeded")
+			}
+			if d := time.Since(start); d < 10*time.Millisecond {
+				t.Errorf("AcquireTimeout returned after %v", d)
+			}
+			go func() {
+				time.Sleep(time.Millisecond)
+				s.Release()
+			}()
+			if !s.AcquireTimeout(time.Minute) {
+				t.Fatal("AcquireTimeout failed after Release")
+			}
+			s.Release()
+
+			s.Stop()
+			if s.TryAcquire() || s.AcquireTimeout(time.Millisecond) {
+				t.Error("acquired after Stop")
+			}
+		})
+	}
+}
+
+func TestSetLimit(t *testing.T) {
+	for name, newSem := range implementations {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(1)
+			s.Acquire()
+			acquired := make(chan bool, 2)
+			for i := 0; i < 2; i++ {
+				go func() { acquired <- s.Acquire() }()
+			}
+			time.Sleep(5 * time.Millisecond)
+			s.SetLimit(3)
+			<-acquired
+			<-acquired
+			if got, want := s.String(), "{ n: 3, effective: 3, used: 3 }"; !strings.HasSuffix(got, want) {
+				t.Errorf("after growing, String() = %q; want suffix %q", got, want)
+			}
+
+			s.SetLimit(1)
+			if got, want := s.String(), "{ n: 1, effective: 3, used: 3 }"; !strings.HasSuffix(got, want) {
+				t.Errorf("after shrinking, String() = %q; want suffix %q", got, want)
+			}
+			errc := make(chan error, 1)
+			go func() { errc <- s.AcquireN(2) }()
+			if err := <-errc; !errors.Is(err, semaphore.ErrTooMany) {
+				t.Errorf("AcquireN(2) with limit 1 = %v; want ErrTooMany", err)
+			}
+			s.Release()
+			s.Release()
+			if s.TryAcquire() {
+				t.Error("acquired while holders exceed the lowered limit")
+			}
+			s.Release()
+			if !s.TryAcquire() {
+				t.Error("could not acquire after holders drained")
+			}
+		})
+	}
+}
+
+func TestOverRelease(t *testing.T) {
+	for name, newSem := range implementations {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(1)
+			// An unmatched Release used to raise the effective limit.
+			s.Release()
+			s.Acquire()
+			if s.TryAcquire() {
+				t.Fatal("unmatched Release raised the limit")
+			}
+			s.Release()
+
+			tok := s.AcquireToken()
+			tok.Release()
+			tok.Release()
+			if !s.TryAcquire() {
+				t.Fatal("Token was not released")
+			}
+			if s.TryAcquire() {
+				t.Fatal("releasing a Token twice raised the limit")
+			}
+			s.Release()
+
+			s.SetStrict(true)
+			func() {
+				defer func() {
+					if r := recover(); r != semaphore.ErrOverRelease {
+						t.Errorf("strict over-release panicked with %v; want ErrOverRelease", r)
+					}
+				}()
+				s.Release()
+			}()
+			if !s.TryAcquire() {
+				t.Fatal("could not acquire after strict over-release")
+			}
+			s.Release()
+
+			s.SetStrict(false)
+			s.Acquire()
+			if err := s.CheckedRelease(2); err != semaphore.ErrOverRelease {
+				t.Errorf("CheckedRelease(2) with 1 held = %v; want ErrOverRelease", err)
+			}
+			if !s.TryAcquire() {
+				t.Fatal("CheckedRelease did not release the held token")
+			}
+			if err := s.CheckedRelease(1); err != nil {
+				t.Errorf("CheckedRelease(1) with 1 held = %v", err)
+			}
+
+			s.Stop()
+			if tok := s.AcquireToken(); tok != nil {
+				t.Error("AcquireToken succeeded after Stop")
+			}
+		})
+	}
+}
+
+func TestDone(t *testing.T) {
+	for name, newSem := range implementations {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(1)
+			if err := s.Err(); err != nil {
+				t.Fatalf("Err() before Stop = %v", err)
+			}
+
+			tick := time.NewTicker(time.Millisecond)
+			defer tick.Stop()
+			acquired := 0
+			for acquired < 3 {
+				select {
+				case ok := <-s.AcquireChan():
+					if !ok {
+						t.Fatal("AcquireChan closed before Stop")
+					}
+					acquired++
+					go s.Release()
+				case <-tick.C:
+				case <-s.Done():
+					t.Fatal("Done closed before Stop")
+				}
+			}
+
+			cause := errors.New("config reload")
+			s.StopWithCause(cause)
+			s.StopWithCause(errors.New("ignored"))
+			s.Stop()
+			select {
+			case <-s.Done():
+			case <-time.After(time.Second):
+				t.Fatal("Done not closed after Stop")
+			}
+			if err := s.Err(); err != cause {
+				t.Errorf("Err() = %v; want %v", err, cause)
+			}
+			if ok := <-s.AcquireChan(); ok {
+				t.Error("AcquireChan yielded a token after Stop")
+			}
+		})
+	}
+}
+
+func TestAcquireChanReserve(t *testing.T) {
+	for name, newSem := range implementations {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(1)
+			select {
+			case <-s.AcquireChan():
+				s.Release()
+			default:
+			}
+			time.Sleep(time.Millisecond)
+			if str := s.String(); !strings.Contains(str, "used: 0") {
+				t.Errorf("String() after unreceived AcquireChan = %s", str)
+			}
+			if !s.TryAcquire() {
+				t.Fatal("TryAcquire failed after unreceived AcquireChan")
+			}
+			s.Release()
+			time.Sleep(time.Millisecond)
+			if err := s.AcquireN(1); err != nil {
+				t.Fatalf("AcquireN after unreceived AcquireChan = %v", err)
+			}
+			s.Release()
+			select {
+			case ok := <-s.AcquireChan():
+				if !ok {
+					t.Fatal("AcquireChan closed before Stop")
+				}
+				s.Release()
+			case <-time.After(time.Second):
+				t.Fatal("AcquireChan yielded no token")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := s.Shutdown(ctx); err != nil {
+				t.Errorf("Shutdown with no holders = %v", err)
+			}
+		})
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	for name, newSem := range implementations {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(3)
+			s.SetTracking(true)
+			s.Acquire()
+			tok := s.AcquireToken()
+			s.AcquireToken().Release()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+			defer cancel()
+			err := s.Shutdown(ctx)
+			var se *semaphore.ShutdownError
+			if !errors.As(err, &se) || !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Shutdown with held tokens = %v; want *ShutdownError", err)
+			}
+			if se.Held != 2 || len(se.Holders) != 1 || !strings.Contains(se.Holders[0], "sem_test.go") {
+				t.Errorf("Shutdown error = %v; want 2 held, 1 holder in sem_test.go", se)
+			}
+			if s.Acquire() {
+				t.Error("Acquire succeeded after Shutdown")
+			}
+
+			go func() {
+				time.Sleep(time.Millisecond)
+				tok.Release()
+				s.Release()
+			}()
+			if err := s.Shutdown(context.Background()); err != nil {
+				t.Errorf("Shutdown after release = %v", err)
+			}
+			s.Wait()
+		})
+	}
+}
+
+func TestGroup(t *testing.T) {
+	g := semaphore.NewGroup(context.Background(), 2)
+	var mu sync.Mutex
+	running, peak := 0, 0
+	errA, errB := errors.New("a"), errors.New("b")
+	for i := 0; i < 10; i++ {
+		i := i
+		g.Go(func(ctx context.Context) error {
+			mu.Lock()
+			running++
+			if running > peak {
+				peak = running
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			switch i {
+			case 3:
+				return errA
+			case 7:
+				return errB
+			case 8:
+				panic("boom")
+			}
+			return nil
+		})
+	}
+	err := g.Wait()
+	if peak != 2 {
+		t.Errorf("Group ran %d functions at once; want 2", peak)
+	}
+	var pe *semaphore.PanicError
+	if !errors.Is(err, errA) || !errors.Is(err, errB) || !errors.As(err, &pe) {
+		t.Fatalf("Wait = %v; want a, b, and a panic", err)
+	}
+	if pe.Value != "boom" || !strings.Contains(string(pe.Stack), "sem_test.go") {
+		t.Errorf("PanicError = %v, %q", pe.Value, pe.Stack)
+	}
+
+	g = semaphore.NewGroup(context.Background(), 1)
+	g.SetCancelOnError(true)
+	calls := 0
+	for i := 0; i < 5; i++ {
+		g.Go(func(ctx context.Context) error {
+			calls++
+			return errA
+		})
+	}
+	err = g.Wait()
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok ||
+		len(joined.Unwrap()) != 1 || !errors.Is(err, errA) || calls != 1 {
+		t.Errorf("Wait with cancel on error = %v after %d calls; want a after 1", err, calls)
+	}
+
+	// A slot freed by a failing function must not start a waiting one.
+	for i := 0; i < 20; i++ {
+		g = semaphore.NewGroup(context.Background(), 1)
+		g.SetCancelOnError(true)
+		fail := make(chan struct{})
+		g.Go(func(ctx context.Context) error {
+			<-fail
+			return errA
+		})
+		time.AfterFunc(time.Millisecond, func() { close(fail) })
+		g.Go(func(ctx context.Context) error {
+			t.Error("Group started a function after an error")
+			return nil
+		})
+		if err := g.Wait(); !errors.Is(err, errA) {
+			t.Errorf("Wait = %v; want a", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g = semaphore.NewGroup(ctx, 1)
+	g.Go(func(ctx context.Context) error {
+		t.Error("Group ran a function after its context was done")
+		return nil
+	})
+	if err := g.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait after cancel = %v; want context.Canceled", err)
+	}
+}
+
+func BenchmarkSemAcqContext(b *testing.B) {
+	s := semaphore.New(1)
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		s.AcquireContext(ctx)
+		s.Release()
+	}
+}
+
+func BenchmarkLSemAcqContext(b *testing.B) {
+	s := semaphore.NewLS(1)
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		s.AcquireContext(ctx)
+		s.Release()
+	}
+}
+
+func BenchmarkSemAcqN(b *testing.B) {
+	s := semaphore.New(4)
+	for i := 0; i < b.N; i++ {
+		s.AcquireN(3)
+		s.ReleaseN(3)
+	}
+}
+
+func BenchmarkLSemAcqN(b *testing.B) {
+	s := semaphore.NewLS(4)
+	for i := 0; i < b.N; i++ {
+		s.AcquireN(3)
+		s.ReleaseN(3)
+	}
+}
+
+func BenchmarkSemTryAcq(b *testing.B) {
+	s := semaphore.New(1)
+	for i := 0; i < b.N; i++ {
+		s.TryAcquire()
+		s.Release()
+	}
+}
+
+func BenchmarkLSemTryAcq(b *testing.B) {
+	s := semaphore.NewLS(1)
+	for i := 0; i < b.N; i++ {
+		s.TryAcquire()
+		s.Release()
+	}
+}
+
+func BenchmarkSemAcqTimeout(b *testing.B) {
+	s := semaphore.New(1)
+	s.Acquire()
+
+	for i := 0; i < b.N; i++ {
+		go s.Release()
+		s.AcquireTimeout(time.Second)
+	}
+}
+
+func BenchmarkLSemAcqTimeout(b *testing.B) {
+	s := semaphore.NewLS(1)
+	s.Acquire()
+
+	for i := 0; i < b.N; i++ {
+		go s.Release()
+		s.AcquireTimeout(time.Second)
+	}
+}
+
+func BenchmarkSemToken(b *testing.B) {
+	s := semaphore.New(1)
+	for i := 0; i < b.N; i++ {
+		s.AcquireToken().Release()
+	}
+}
+
+func BenchmarkLSemToken(b *testing.B) {
+	s := semaphore.NewLS(1)
+	for i := 0; i < b.N; i++ {
+		s.AcquireToken().Release()
+	}
+}
+
+func BenchmarkSemAcqChan(b *testing.B) {
+	s := semaphore.New(1)
+	for i := 0; i < b.N; i++ {
+		<-s.AcquireChan()
+		s.Release()
+	}
+}
+
+func BenchmarkLSemAcqChan(b *testing.B) {
+	s := semaphore.NewLS(1)
+	for i := 0; i < b.N; i++ {
+		<-s.AcquireChan()
+		s.Release()
+	}
+	s.Stop()
+}
+
+func BenchmarkASemAcq(b *testing.B) {
+	s := semaphore.NewAtomic(1)
+	for i := 0; i < b.N; i++ {
+		s.Acquire()
+		s.Release()
+	}
+}
+
+func BenchmarkASemAcqRelease(b *testing.B) {
+	s := semaphore.NewAtomic(1)
+	s.Acquire()
+
+	for i := 0; i < b.N; i++ {
+		go s.Release()
+		s.Acquire()
+	}
+}
+
+func BenchmarkASemAcqStop(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		s := semaphore.NewAtomic(1)
+		s.Acquire()
+		go s.Stop()
+		s.Acquire()
+	}
+}
+
+func BenchmarkASemLoop1(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		s := semaphore.NewAtomic(1)
+		go s.Stop()
+		for s.Acquire() {
+		}
+	}
+}
+
+func BenchmarkASemLoop5(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		s := semaphore.NewAtomic(5)
+		go s.Stop()
+		for s.Acquire() {
+		}
+	}
+}
+
+func BenchmarkASemLoop50(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		s := semaphore.NewAtomic(50)
+		go s.Stop()
+		for s.Acquire() {
+		}
+	}
+}
+
+func BenchmarkASemSleep(b *testing.B) {
+	s := semaphore.NewAtomic(1)
+	s.Acquire()
+	for i := 0; i < b.N; i++ {
+		go func() {
+			time.Sleep(5 * time.Nanosecond)
+			s.Release()
+		}()
+		s.Acquire()
+	}
+}
+
+func BenchmarkASemWait(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		s := semaphore.NewAtomic(10)
+		for j := 0; j < 100; j++ {
+			go func() {
+				for s.Acquire() {
+					time.Sleep(1 * time.Nanosecond)
+					s.Release()
+				}
+			}()
+		}
+		time.Sleep(100 * time.Nanosecond)
+		s.Stop()
+		s.Wait()
+	}
+}
+
+func BenchmarkASemAcqContext(b *testing.B) {
+	s := semaphore.NewAtomic(1)
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		s.AcquireContext(ctx)
+		s.Release()
+	}
+}
+
+func BenchmarkASemTryAcq(b *testing.B) {
+	s := semaphore.NewAtomic(1)
+	for i := 0; i < b.N; i++ {
+		s.TryAcquire()
+		s.Release()
+	}
+}
+
+func BenchmarkGroup(b *testing.B) {
+	g := semaphore.NewGroup(context.Background(), 10)
+	for i := 0; i < b.N; i++ {
+		g.Go(func(ctx context.Context) error {
+			return nil
+		})
+	}
+	g.Wait()
+}
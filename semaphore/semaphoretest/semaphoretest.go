@@ -0,0 +1,233 @@
+// Package semaphoretest implements behavioral tests for implementations
+// of semaphore.Interface.
+package semaphoretest
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/go-utils/semaphore"
+)
+
+// timeout bounds how long a test waits for an operation that should not
+// block before failing it as a deadlock.
+const timeout = 5 * time.Second
+
+// Run runs the behavioral tests as subtests of t. newSem must return a
+// new, open semaphore allowing n tokens at once.
+func Run(t *testing.T, newSem func(n int) semaphore.Interface) {
+	t.Run("Capacity", func(t *testing.T) { testCapacity(t, newSem) })
+	t.Run("StopWakesWaiters", func(t *testing.T) { testStopWakesWaiters(t, newSem) })
+	t.Run("ReleaseAfterStop", func(t *testing.T) { testReleaseAfterStop(t, newSem) })
+	t.Run("Poll", func(t *testing.T) { testPoll(t, newSem) })
+	t.Run("TryAcquire", func(t *testing.T) { testTryAcquire(t, newSem) })
+	t.Run("AcquireContext", func(t *testing.T) { testAcquireContext(t, newSem) })
+	t.Run("Stress", func(t *testing.T) { testStress(t, newSem) })
+}
+
+// within fails t if f does not return within timeout.
+func within(t *testing.T, what string, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatalf("%s did not return within %v", what, timeout)
+	}
+}
+
+// counter tracks the number of tokens held, failing if it exceeds max.
+type counter struct {
+	t        *testing.T
+	max      int32
+	held     int32
+	highMark int32
+}
+
+func (c *counter) acquired() {
+	n := atomic.AddInt32(&c.held, 1)
+	if n > c.max {
+		c.t.Errorf("%d tokens held; capacity is %d", n, c.max)
+	}
+	for {
+		high := atomic.LoadInt32(&c.highMark)
+		if n <= high || atomic.CompareAndSwapInt32(&c.highMark, high, n) {
+			return
+		}
+	}
+}
+
+func (c *counter) released() {
+	atomic.AddInt32(&c.held, -1)
+}
+
+func testCapacity(t *testing.T, newSem func(int) semaphore.Interface) {
+	const n, workers, rounds = 3, 20, 50
+	s := newSem(n)
+	c := counter{t: t, max: n}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				if !s.Acquire() {
+					t.Error("Acquire failed on open semaphore")
+					return
+				}
+				c.acquired()
+				time.Sleep(time.Microsecond)
+				c.released()
+				s.Release()
+			}
+		}()
+	}
+	within(t, "workers", wg.Wait)
+	if c.highMark != n {
+		t.Logf("at most %d of %d tokens were held at once", c.highMark, n)
+	}
+	s.Stop()
+}
+
+func testStopWakesWaiters(t *testing.T, newSem func(int) semaphore.Interface) {
+	const waiters = 10
+	s := newSem(1)
+	if !s.Acquire() {
+		t.Fatal("Acquire failed on open semaphore")
+	}
+	results := make(chan bool, waiters)
+	for i := 0; i < waiters; i++ {
+		go func() { results <- s.Acquire() }()
+	}
+	time.Sleep(10 * time.Millisecond)
+	s.Stop()
+	within(t, "blocked Acquire after Stop", func() {
+		for i := 0; i < waiters; i++ {
+			if <-results {
+				t.Error("blocked Acquire succeeded after Stop")
+			}
+		}
+	})
+	s.Release()
+}
+
+func testReleaseAfterStop(t *testing.T, newSem func(int) semaphore.Interface) {
+	s := newSem(2)
+	s.Acquire()
+	s.Acquire()
+	s.Stop()
+	within(t, "Release after Stop", func() {
+		s.Release()
+		s.Release()
+	})
+	within(t, "repeated Stop", s.Stop)
+	if s.Acquire() {
+		t.Error("Acquire succeeded after Stop and Release")
+	}
+}
+
+func testPoll(t *testing.T, newSem func(int) semaphore.Interface) {
+	s := newSem(1)
+	if !s.Poll() {
+		t.Error("Poll() = false before Stop")
+	}
+	select {
+	case <-s.Done():
+		t.Error("Done closed before Stop")
+	default:
+	}
+	s.Stop()
+	if s.Poll() {
+		t.Error("Poll() = true after Stop")
+	}
+	select {
+	case <-s.Done():
+	default:
+		t.Error("Done not closed after Stop")
+	}
+}
+
+func testTryAcquire(t *testing.T, newSem func(int) semaphore.Interface) {
+	s := newSem(2)
+	if !s.TryAcquire() || !s.TryAcquire() {
+		t.Fatal("TryAcquire failed on free semaphore")
+	}
+	if s.TryAcquire() {
+		t.Fatal("TryAcquire succeeded on full semaphore")
+	}
+	s.Release()
+	if !s.TryAcquire() {
+		t.Fatal("TryAcquire failed after Release")
+	}
+	s.Release()
+	s.Release()
+	s.Stop()
+	if s.TryAcquire() {
+		t.Error("TryAcquire succeeded after Stop")
+	}
+}
+
+func testAcquireContext(t *testing.T, newSem func(int) semaphore.Interface) {
+	s := newSem(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.AcquireContext(ctx); err != context.Canceled {
+		t.Errorf("AcquireContext with canceled context = %v; want %v", err, context.Canceled)
+	}
+	if err := s.AcquireContext(context.Background()); err != nil {
+		t.Fatalf("AcquireContext on free semaphore = %v", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := s.AcquireContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("AcquireContext on full semaphore = %v; want %v", err, context.DeadlineExceeded)
+	}
+	s.Release()
+	s.Stop()
+	if err := s.AcquireContext(context.Background()); err != semaphore.ErrStopped {
+		t.Errorf("AcquireContext after Stop = %v; want %v", err, semaphore.ErrStopped)
+	}
+}
+
+// testStress mixes every way of acquiring tokens from many goroutines
+// and stops the semaphore while they run. Run it with the race detector.
+func testStress(t *testing.T, newSem func(int) semaphore.Interface) {
+	const n, workers = 4, 32
+	s := newSem(n)
+	c := counter{t: t, max: n}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; s.Poll(); j++ {
+				var ok bool
+				switch (i + j) % 3 {
+				case 0:
+					ok = s.Acquire()
+				case 1:
+					ok = s.TryAcquire()
+				case 2:
+					ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+					ok = s.AcquireContext(ctx) == nil
+					cancel()
+				}
+				if ok {
+					c.acquired()
+					c.released()
+					s.Release()
+				}
+			}
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	s.Stop()
+	within(t, "workers after Stop", wg.Wait)
+}
@@ -0,0 +1,68 @@
+package semaphore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// A ShutdownError is returned by Shutdown when tokens are still held as
+// its context is done.
+type ShutdownError struct {
+	// Held is the number of tokens still held.
+	Held int
+	// Holders lists where the outstanding Tokens were acquired, as
+	// "file:line", if tracking was turned on with SetTracking.
+	Holders []string
+	// Err is the error of the context.
+	Err error
+}
+
+func (e *ShutdownError) Error() string {
+	msg := fmt.Sprintf("semaphore: shutdown: %v with %d tokens held", e.Err, e.Held)
+	if len(e.Holders) > 0 {
+		msg += " by " + strings.Join(e.Holders, ", ")
+	}
+	return msg
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+// Shutdown closes the Semaphore as by Stop() and waits until all acquired
+// tokens have been released or ctx is done. In the latter case, it returns
+// a *ShutdownError reporting the tokens still held.
+func (s *Semaphore) Shutdown(ctx context.Context) error {
+	s.Stop()
+	select {
+	case <-s.wait:
+		return nil
+	case <-ctx.Done():
+	}
+	select {
+	case n := <-s.held:
+		return &ShutdownError{n, s.tracker.list(), ctx.Err()}
+	case <-s.wait:
+		return nil
+	}
+}
+
+// Shutdown closes the LockingSemaphore as by Stop() and waits until all
+// acquired tokens have been released or ctx is done. In the latter case,
+// it returns a *ShutdownError reporting the tokens still held.
+func (s *LockingSemaphore) Shutdown(ctx context.Context) error {
+	s.Stop()
+	select {
+	case <-s.drained:
+		return nil
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	n := s.held()
+	s.mu.Unlock()
+	if n == 0 {
+		return nil
+	}
+	return &ShutdownError{n, s.tracker.list(), ctx.Err()}
+}
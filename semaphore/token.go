@@ -0,0 +1,119 @@
+package semaphore
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// A Token is a handle to a token acquired with AcquireToken. Unlike calls
+// to Release, releasing a Token more than once has no effect, so a Token
+// may be released both by a deferred call and on an early path.
+type Token struct {
+	release  func(n int)
+	released atomic.Bool
+	tracker  *tracker
+}
+
+// Release returns the token to its semaphore. Only the first call to
+// Release has an effect.
+func (t *Token) Release() {
+	if t.released.CompareAndSwap(false, true) {
+		t.tracker.remove(t)
+		t.release(1)
+	}
+}
+
+// tracker records the callers of AcquireToken for outstanding Tokens
+// while tracking is on.
+type tracker struct {
+	mu      sync.Mutex
+	on      bool
+	holders map[*Token]string
+}
+
+func (tr *tracker) setTracking(on bool) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.on = on
+	if !on {
+		tr.holders = nil
+	}
+}
+
+// newToken returns a Token releasing with release, recording the caller
+// of AcquireToken if tracking is on.
+func (tr *tracker) newToken(release func(n int)) *Token {
+	t := &Token{release: release, tracker: tr}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if tr.on {
+		if tr.holders == nil {
+			tr.holders = make(map[*Token]string)
+		}
+		caller := "unknown"
+		if _, file, line, ok := runtime.Caller(2); ok {
+			caller = fmt.Sprintf("%s:%d", file, line)
+		}
+		tr.holders[t] = caller
+	}
+	return t
+}
+
+func (tr *tracker) remove(t *Token) {
+	tr.mu.Lock()
+	delete(tr.holders, t)
+	tr.mu.Unlock()
+}
+
+// list returns the callers holding Tokens, sorted.
+func (tr *tracker) list() []string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	var holders []string
+	for _, caller := range tr.holders {
+		holders = append(holders, caller)
+	}
+	sort.Strings(holders)
+	return holders
+}
+
+// AcquireToken is like Acquire, but returns a Token to release the
+// acquired token, or nil if the Semaphore has been closed with Stop().
+func (s *Semaphore) AcquireToken() *Token {
+	if !s.Acquire() {
+		return nil
+	}
+	return s.tracker.newToken(s.ReleaseN)
+}
+
+// SetTracking sets whether the Semaphore records where each outstanding
+// Token was acquired, for the ShutdownError of Shutdown. Tokens acquired
+// while tracking is off, and tokens acquired without a Token, are not
+// recorded.
+func (s *Semaphore) SetTracking(on bool) {
+	s.tracker.setTracking(on)
+}
+
+// AcquireToken is like Acquire, but returns a Token to release the
+// acquired token, or nil if the LockingSemaphore has been closed with
+// Stop().
+func (s *LockingSemaphore) AcquireToken() *Token {
+	if !s.Acquire() {
+		return nil
+	}
+	return s.tracker.newToken(s.ReleaseN)
+}
+
+// SetTracking sets whether the LockingSemaphore records where each
+// outstanding Token was acquired, for the ShutdownError of Shutdown.
+// Tokens acquired while tracking is off, and tokens acquired without a
+// Token, are not recorded.
+func (s *LockingSemaphore) SetTracking(on bool) {
+	s.tracker.setTracking(on)
+}